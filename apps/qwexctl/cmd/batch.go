@@ -1,8 +1,11 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"time"
 
@@ -10,87 +13,481 @@ import (
 	"github.com/Quatton/qwex/apps/qwexctl/internal/connect"
 	"github.com/Quatton/qwex/apps/qwexctl/internal/pods"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 var (
 	follow    bool
 	batchName string
 	image     string
+
+	concurrencyGroup  string
+	concurrencyPolicy string
+	dedupWindow       time.Duration
+	strictLint        bool
+	batchInputs       []string
+	batchEnv          []string
+	scratchSize       string
+	batchLabels       []string
+	matrixValues      []string
+	maxParallel       int
+	scriptPath        string
+	jobFilePath       string
+	expectFiles       []string
+	expectMetrics     []string
+	maxDuration       time.Duration
+	smoke             bool
+	terminationGrace  time.Duration
+	batchOutput       string
+	batchCPU          string
+	batchMemory       string
+	batchGPU          int64
 )
 
 var batchCmd = &cobra.Command{
-	Use:   "batch [command] [args...]",
+	Use:   "batch [command] [args...] | --script FILE [args...] | --file job.yaml",
 	Short: "Submit a batch job to the remote workspace",
 	Long: `Submit a batch job that runs in an isolated worktree.
-The job will sync your current commit and execute the specified command.`,
-	Args: cobra.MinimumNArgs(1),
+The job will sync your current commit and execute the specified command.
+With --script, a single local file is uploaded and run with the interpreter
+for its extension instead; no repository or dev workspace is needed.
+With --script -, a shell script is read from stdin and run verbatim as
+command.sh, e.g. cat analysis.sh | qwexctl batch --script -
+
+With --file, the run is defined in a YAML file validated against
+job.schema.json. Flags given on the command line override its values;
+env, labels and inputs from both are merged, and a command on the
+command line replaces the file's.
+
+  command: [python, train.py, --epochs, "10"]
+  image: ghcr.io/acme/train:latest
+  env: {WANDB_PROJECT: bert}
+  labels: {sweep: bert}
+  inputs: [{dest: data/train.csv, url: "s3://bucket/train.csv"}]
+  scratch: 20Gi
+  resources: {cpu: "4", memory: 16Gi, gpu: 1}
+  expect: {files: [out/model.pt], metrics: ["accuracy >= 0.9"]}
+  maxDuration: 6h
+
+--expect-file and --expect-metric are checked after the command exits 0;
+if any fails, the run fails with reason contract_violation. Metrics are read
+from the flat JSON object the job writes to $QWEX_METRICS_FILE.
+
+Cancelled runs get SIGTERM, then --termination-grace (default 30s) for
+trap handlers to save their work before SIGKILL.
+
+Values passed with --env are Go templates rendered at submit time:
+  {{ .RunID }} {{ .Job }} {{ .Sha }} {{ .Image }}
+  {{ .Namespace }} {{ .Workspace }} {{ .User.Login }}
+  {{ secret "WANDB_API_KEY" }}        key WANDB_API_KEY of secret wandb-api-key
+  {{ secret "wandb" "api-key" }}      key api-key of secret wandb
+A secret reference must be the whole value. It is injected as a secretKeyRef,
+so the value is never written into the job spec. When following or reading
+logs, qwexctl gets the referenced secrets to mask their values in the output;
+that needs get on secrets, and secrets RBAC won't let it read stay unmasked.
+Defaults come from 'env' in the user and project config; see 'qwexctl env'.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		localRepoPath := connect.GetLocalRepoPath(cfgFile)
+		if len(args) > 0 && args[0] == "--" {
+			args = args[1:]
+		}
 
-		ctx := cmd.Context()
-		svc := ctx.Value("service").(*Service)
+		switch batchOutput {
+		case "text":
+		case "json":
+			if len(matrixValues) > 0 {
+				return withExitCode(exitUsage, fmt.Errorf("--output json can't be used with --matrix"))
+			}
+			jsonOutput = true
+		default:
+			return withExitCode(exitUsage, fmt.Errorf("invalid --output %q (expected text or json)", batchOutput))
+		}
+
+		var resources *batch.Resources
+		var inputs []batch.Input
+		contract := &batch.Contract{}
+		if jobFilePath != "" {
+			if scriptPath != "" {
+				return withExitCode(exitUsage, fmt.Errorf("--file and --script can't be used together"))
+			}
+			jobFile, err := batch.LoadJobFile(jobFilePath)
+			if err != nil {
+				return err
+			}
+			if len(args) == 0 {
+				args = jobFile.Command
+			}
+			if !cmd.Flags().Changed("image") {
+				image = jobFile.Image
+			}
+			if !cmd.Flags().Changed("job") && jobFile.Name != "" {
+				batchName = jobFile.Name
+			}
+			if !cmd.Flags().Changed("scratch") {
+				scratchSize = jobFile.Scratch
+			}
+			batchEnv = append(jobFile.EnvValues(), batchEnv...)
+			batchLabels = append(jobFile.LabelValues(), batchLabels...)
+			resources = jobFile.Resources
+			inputs = jobFile.Inputs
+			if jobFile.Expect != nil {
+				contract = jobFile.Expect
+			}
+			if !cmd.Flags().Changed("max-duration") {
+				maxDuration, _ = jobFile.Duration()
+			}
+			if !cmd.Flags().Changed("termination-grace") {
+				terminationGrace, _ = jobFile.Grace()
+			}
+		}
+
+		if len(args) == 0 && scriptPath == "" {
+			return withExitCode(exitUsage, fmt.Errorf("requires a command, --script or --file"))
+		}
+
+		// --cpu, --memory and --gpu override the job file's resources one by one
+		if batchCPU != "" || batchMemory != "" || batchGPU != 0 {
+			overridden := batch.Resources{}
+			if resources != nil {
+				overridden = *resources
+			}
+			if batchCPU != "" {
+				overridden.CPU = batchCPU
+			}
+			if batchMemory != "" {
+				overridden.Memory = batchMemory
+			}
+			if batchGPU != 0 {
+				overridden.GPU = batchGPU
+			}
+			if err := overridden.Validate(); err != nil {
+				return withExitCode(exitUsage, err)
+			}
+			resources = &overridden
+		}
+
+		policy, err := batch.ParseConcurrencyPolicy(concurrencyPolicy)
+		if err != nil {
+			return err
+		}
+
+		if concurrencyGroup != "" {
+			if err := batch.ValidateConcurrencyGroup(concurrencyGroup); err != nil {
+				return err
+			}
+		}
+
+		for _, value := range batchInputs {
+			input, err := batch.ParseInput(value)
+			if err != nil {
+				return err
+			}
+			inputs = append(inputs, input)
+		}
+
+		if err := batch.ValidateSeconds("--max-duration", maxDuration); err != nil {
+			return withExitCode(exitUsage, err)
+		}
+		// A truncated 0s grace period would mean an immediate SIGKILL
+		if err := batch.ValidateSeconds("--termination-grace", terminationGrace); err != nil {
+			return withExitCode(exitUsage, err)
+		}
+
+		contract.Files = append(contract.Files, expectFiles...)
+		contract.Metrics = append(contract.Metrics, expectMetrics...)
+		if err := contract.Validate(); err != nil {
+			return err
+		}
+		if contract.Empty() {
+			contract = nil
+		}
+
+		labels, err := batch.ParseLabels(batchLabels)
+		if err != nil {
+			return err
+		}
+
+		matrix, err := batch.ParseMatrix(matrixValues)
+		if err != nil {
+			return err
+		}
 
-		podService := &pods.Service{K8s: svc.K8s.Clientset, Namespace: svc.Namespace}
-		dep, err := podService.GetOrCreateDevelopmentDeployment(ctx, pods.Active)
+		scratch, err := batch.ParseScratchSize(scratchSize)
 		if err != nil {
 			return err
 		}
 
-		pod, err := podService.GetPodFromDeployment(ctx, dep)
+		merged, err := effectiveEnv(batchEnv)
 		if err != nil {
 			return err
 		}
+		var env []batch.EnvVar
+		for _, e := range merged {
+			env = append(env, e.EnvVar)
+		}
+
+		localRepoPath := connect.GetLocalRepoPath(cfgFile)
 
-		connectService := connect.NewService(svc.K8s.Clientset, svc.K8s.Config, namespace, pod.Name, pods.SyncContainerName, localRepoPath)
+		ctx := cmd.Context()
+		svc := ctx.Value("service").(*Service)
+
+		podService, err := newPodService(svc)
+		if err != nil {
+			return err
+		}
+
+		var script *batch.Script
+		var connectService *connect.Service
+		if scriptPath != "" {
+			content, name, err := readScript(scriptPath)
+			if err != nil {
+				return err
+			}
+			script, err = batch.NewScript(name, content)
+			if err != nil {
+				return err
+			}
+			connectService = connect.NewService(svc.K8s.Clientset, svc.K8s.Config, namespace, "", "", localRepoPath)
+		} else {
+			dep, err := podService.GetOrCreateDevelopmentDeployment(ctx, pods.Active)
+			if err != nil {
+				return err
+			}
+
+			pod, err := podService.GetPodFromDeployment(ctx, dep)
+			if err != nil {
+				return err
+			}
+
+			connectService = connect.NewService(svc.K8s.Clientset, svc.K8s.Config, namespace, pod.Name, pods.SyncContainerName, localRepoPath)
+		}
 
 		// Make this configurable later?
 		targetWorkDir := batch.BatchWorkDir
 
-		targetImage := image
-		if targetImage == "" {
-			targetImage = batch.DemoImage
+		targetImage, err := batchImage(image)
+		if err != nil {
+			return err
 		}
 
-		if args[0] == "--" {
-			args = args[1:]
+		var command, cmdArgs []string
+		if script != nil {
+			command, err = batch.ScriptCommand(script.Name)
+			if err != nil {
+				return err
+			}
+			cmdArgs = args
+		} else {
+			command = []string{args[0]}
+			if len(args) > 1 {
+				cmdArgs = args[1:]
+			}
 		}
 
-		command := []string{args[0]}
-		var cmdArgs []string
-		if len(args) > 1 {
-			cmdArgs = args[1:]
+		batchService := batch.NewService(connectService, "", targetImage, command, cmdArgs, targetWorkDir, batchName)
+		batchService.SyncImage = podService.Images.Sync
+		batchService.Workspace = podService.Workspace
+		batchSettings{
+			ConcurrencyGroup:  concurrencyGroup,
+			ConcurrencyPolicy: policy,
+			DedupWindow:       dedupWindow,
+			Inputs:            inputs,
+			Env:               env,
+			Scratch:           scratch,
+			Resources:         resources,
+			Contract:          contract,
+			MaxDuration:       maxDuration,
+			TerminationGrace:  terminationGrace,
+			Labels:            labels,
+			Script:            script,
+			Smoke:             smoke,
+		}.apply(batchService)
+		batchService.RedactPatterns, err = redactPatterns()
+		if err != nil {
+			return err
 		}
 
-		batchService := batch.NewService(connectService, "", targetImage, command, cmdArgs, targetWorkDir, batchName)
+		envNames := batchService.EnvNames()
+		for _, axis := range matrix {
+			envNames = append(envNames, axis.Name)
+		}
+
+		warnings := batch.LintCommand(append(command, cmdArgs...), targetWorkDir, envNames, localRepoPath)
+		if script == nil && targetImage == podService.Images.Dev {
+			// The dev container runs the same image, so we can check PATH for free
+			if warning := batchService.ProbeCommand(ctx, pods.DevContainerName); warning != nil {
+				warnings = append(warnings, *warning)
+			}
+		}
+		for _, warning := range warnings {
+			fmt.Fprintf(os.Stderr, "⚠️  %s\n", warning)
+		}
+		if strictLint && len(warnings) > 0 {
+			return withExitCode(exitPreflight, fmt.Errorf("%d preflight warning(s), not submitting (--strict)", len(warnings)))
+		}
+
+		if script == nil {
+			say("🔄 Syncing workspace...\n")
+		}
+		if len(matrix) > 0 {
+			return runMatrix(ctx, batchService, batch.ExpandMatrix(matrix), maxParallel)
+		}
 
-		fmt.Println("🔄 Syncing workspace...")
-		job, err := batchService.EnsureSyncAndSubmitJob(ctx)
+		job, err := batchService.Submit(ctx)
 		if err != nil {
 			return err
 		}
 
 		runID := job.Labels["qwex.dev/run-id"]
-		fmt.Printf("✅ Job submitted: %s (run-id: %s)\n", job.Name, runID)
+		if quiet && !jsonOutput {
+			fmt.Println(runID)
+		}
+		switch {
+		case batch.IsJobSucceeded(job):
+			say("♻️  Identical run already succeeded: %s (run-id: %s)\n", job.Name, runID)
+		case smoke:
+			say("💨 Smoke run submitted: %s (run-id: %s)\n", job.Name, runID)
+		default:
+			say("✅ Job submitted: %s (run-id: %s)\n", job.Name, runID)
+		}
 
 		if follow {
-			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+			ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
 			defer cancel()
 
-			if err := batchService.FollowRunLogs(ctx, runID, os.Stdout); err != nil {
-				fmt.Printf("Error following logs: %v\n", err)
-				return nil
+			// In quiet and JSON mode stdout only carries the results
+			var logOut io.Writer = os.Stdout
+			if quiet || jsonOutput {
+				logOut = os.Stderr
+			}
+
+			status := newStatusLine(logOut)
+			batchService.OnWait = status.Update
+			err := batchService.FollowRunLogs(ctx, runID, status)
+			status.Stop()
+			// A scratch or contract failure still ends the run; report it like any other failure
+			var runFailed *batch.RunFailedError
+			if err != nil && !errors.As(err, &runFailed) {
+				return withExitCode(exitError, fmt.Errorf("error following logs: %w", err))
+			}
+
+			job, err := batchService.WaitForRunFinished(ctx, runID, 30*time.Second)
+			if err != nil {
+				return withExitCode(exitError, fmt.Errorf("error waiting for run %s to finish: %w", runID, err))
+			}
+			switch {
+			case jsonOutput:
+				if err := printRunJSON(job, runFailed); err != nil {
+					return err
+				}
+			case quiet:
+				fmt.Println(batch.JobStatus(job))
+			default:
+				printRunSummary(job)
+			}
+			if runFailed != nil {
+				return runFailed
+			}
+			if batch.JobStatus(job) == "Failed" {
+				return withExitCode(exitRunFailed, fmt.Errorf("run %s failed", runID))
 			}
 		} else {
-			fmt.Printf("💡 To view logs, run: qwexctl logs -f %s\n", runID)
+			if jsonOutput {
+				if err := printRunJSON(job, nil); err != nil {
+					return err
+				}
+			}
+			say("💡 To view logs, run: qwexctl logs -f %s\n", runID)
 		}
 
 		return nil
 	},
 }
 
+// readScript reads the --script file, or stdin for "-"
+func readScript(scriptPath string) ([]byte, string, error) {
+	if scriptPath != "-" {
+		content, err := os.ReadFile(scriptPath)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read script: %w", err)
+		}
+		return content, scriptPath, nil
+	}
+
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		return nil, "", withExitCode(exitUsage, fmt.Errorf("--script - reads the script from stdin, but nothing was piped in"))
+	}
+	content, err := io.ReadAll(io.LimitReader(os.Stdin, batch.MaxScriptSize+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read script from stdin: %w", err)
+	}
+	if len(bytes.TrimSpace(content)) == 0 {
+		return nil, "", withExitCode(exitUsage, fmt.Errorf("the script on stdin is empty"))
+	}
+	return content, batch.StdinScriptName, nil
+}
+
+// batchSettings are the run options parsed from flags and the job file
+type batchSettings struct {
+	ConcurrencyGroup  string
+	ConcurrencyPolicy batch.ConcurrencyPolicy
+	DedupWindow       time.Duration
+	Inputs            []batch.Input
+	Env               []batch.EnvVar
+	Scratch           *resource.Quantity
+	Resources         *batch.Resources
+	Contract          *batch.Contract
+	MaxDuration       time.Duration
+	TerminationGrace  time.Duration
+	Labels            map[string]string
+	Script            *batch.Script
+	Smoke             bool
+}
+
+// apply copies the settings onto s; --smoke goes last so its resources,
+// env, deadline and label are layered over everything the user set
+func (b batchSettings) apply(s *batch.Service) {
+	s.ConcurrencyGroup = b.ConcurrencyGroup
+	s.ConcurrencyPolicy = b.ConcurrencyPolicy
+	s.DedupWindow = b.DedupWindow
+	s.Inputs = b.Inputs
+	s.Env = b.Env
+	s.Scratch = b.Scratch
+	s.Resources = b.Resources
+	s.Contract = b.Contract
+	s.MaxDuration = b.MaxDuration
+	s.TerminationGrace = b.TerminationGrace
+	s.Labels = b.Labels
+	s.Script = b.Script
+	if b.Smoke {
+		s.Smoke()
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(batchCmd)
 	batchCmd.Flags().BoolVarP(&follow, "follow", "f", false, "Follow job logs after submission")
+	batchCmd.Flags().StringVarP(&batchOutput, "output", "o", "text", "Output format: text or json (the run as a JSON object on stdout; progress and logs go to stderr)")
 	batchCmd.Flags().StringVarP(&batchName, "job", "j", "job", "Job name prefix")
-	batchCmd.Flags().StringVarP(&image, "image", "i", "", "Container image to use (default: uv alpine or whatever that full name is idk)")
+	batchCmd.Flags().StringVarP(&image, "image", "i", "", "Container image to use (default: images.batch from config, else the uv python image)")
+	batchCmd.Flags().StringVar(&concurrencyGroup, "concurrency-group", "", "Only one run per group executes at a time")
+	batchCmd.Flags().StringVar(&concurrencyPolicy, "concurrency-policy", string(batch.ConcurrencyQueue), "What to do with active runs in the group: queue or cancel")
+	batchCmd.Flags().BoolVar(&strictLint, "strict", false, "Fail instead of warning when preflight checks find problems")
+	batchCmd.Flags().StringArrayVar(&batchInputs, "input", nil, "Download a file into the workdir before running, as DEST=URL[@sha256:HEX] (repeatable; checksummed files are cached)")
+	batchCmd.Flags().StringArrayVarP(&batchEnv, "env", "e", nil, "Set NAME=VALUE in the job environment (repeatable, VALUE is a template, see --help)")
+	batchCmd.Flags().StringArrayVarP(&batchLabels, "label", "l", nil, "Add a KEY=VALUE label to the run for filtering, e.g. sweep=bert (repeatable)")
+	batchCmd.Flags().StringArrayVar(&matrixValues, "matrix", nil, "Submit one run per combination, e.g. --matrix LR=0.1,0.01 --matrix SEED=1,2,3 (set as env vars; waits for all runs)")
+	batchCmd.Flags().IntVar(&maxParallel, "max-parallel", 0, "With --matrix, keep at most this many runs active at once (0 for no limit)")
+	batchCmd.Flags().StringVar(&jobFilePath, "file", "", "Read the run definition from a YAML job file (see --help)")
+	batchCmd.Flags().StringVar(&scriptPath, "script", "", "Upload and run a single local file (.py, .sh, .R, .jl, .js, .ts) instead of the synced repository, or - for a shell script on stdin")
+	batchCmd.Flags().StringVar(&batchCPU, "cpu", "", "CPU for the run, e.g. 4 or 500m")
+	batchCmd.Flags().StringVar(&batchMemory, "memory", "", "Memory for the run, e.g. 16Gi")
+	batchCmd.Flags().Int64Var(&batchGPU, "gpu", 0, "Number of GPUs for the run")
+	batchCmd.Flags().StringVar(&scratchSize, "scratch", "", "Mount a scratch dir at /scratch (also TMPDIR) and cap the job's disk usage, e.g. 20Gi")
+	batchCmd.Flags().StringArrayVar(&expectFiles, "expect-file", nil, "Fail the run if this file is missing or empty after the command succeeds (repeatable)")
+	batchCmd.Flags().StringArrayVar(&expectMetrics, "expect-metric", nil, "Fail the run unless a metric in $QWEX_METRICS_FILE passes, e.g. 'accuracy >= 0.9' (repeatable)")
+	batchCmd.Flags().DurationVar(&maxDuration, "max-duration", 0, "Fail the run once it has been active this long, e.g. 6h")
+	batchCmd.Flags().DurationVar(&terminationGrace, "termination-grace", 0, "Time a cancelled run gets between SIGTERM and SIGKILL to clean up (default 30s)")
+	batchCmd.Flags().BoolVar(&smoke, "smoke", false, "Check the command, image and env wiring first: QWEX_SMOKE=1, 500m CPU / 1Gi memory, no GPUs, at most 5m")
+	batchCmd.Flags().DurationVar(&dedupWindow, "dedup-window", 0, "Reuse an identical run that succeeded within this window instead of submitting (e.g. 1h)")
 }
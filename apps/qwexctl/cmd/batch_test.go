@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Quatton/qwex/apps/qwexctl/internal/batch"
+	"github.com/Quatton/qwex/apps/qwexctl/internal/connect"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// scriptService submits a small script, which needs no dev pod or git sync
+func scriptService(t *testing.T, client *fake.Clientset) (*batch.Service, *batch.Script) {
+	t.Helper()
+	s := batch.NewService(&connect.Service{Client: client, Namespace: "ns"}, "", "python:3.12", nil, nil, batch.BatchWorkDir, "")
+
+	script, err := batch.NewScript("train.py", []byte("print('hi')\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Command, err = batch.ScriptCommand(script.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Script = script
+	return s, script
+}
+
+func TestBatchSettingsSmokeKeepsLabels(t *testing.T) {
+	s, script := scriptService(t, fake.NewSimpleClientset())
+	batchSettings{
+		Labels: map[string]string{"sweep": "bert"},
+		Script: script,
+		Smoke:  true,
+	}.apply(s)
+
+	job, err := s.Submit(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !batch.IsSmokeRun(job.Labels) {
+		t.Errorf("submitted job labels = %v, want %s=true", job.Labels, batch.SmokeLabel)
+	}
+	if job.Labels["sweep"] != "bert" {
+		t.Errorf("submitted job labels = %v, want sweep=bert", job.Labels)
+	}
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+
+	"github.com/Quatton/qwex/apps/qwexctl/internal/connect"
+	"github.com/Quatton/qwex/apps/qwexctl/internal/pods"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+var printCodeURI bool
+
+var codeCmd = &cobra.Command{
+	Use:   "code",
+	Short: "Open VS Code attached to the remote workspace (Syncs first)",
+	Long: `Open VS Code attached to the development container through the
+Dev Containers / Kubernetes extensions. The folder opened is the same
+subdirectory of /workspace you are in locally.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		svc := cmd.Context().Value("service").(*Service)
+		ctx := cmd.Context()
+
+		localRepoPath := connect.GetLocalRepoPath(cfgFile)
+
+		podService, err := newPodService(svc)
+		if err != nil {
+			return err
+		}
+
+		dep, err := podService.GetOrCreateDevelopmentDeployment(ctx, pods.Active)
+		if err != nil {
+			return err
+		}
+
+		pod, err := podService.GetPodFromDeployment(ctx, dep)
+		if err != nil {
+			return err
+		}
+
+		connectService := connect.NewService(svc.K8s.Clientset, svc.K8s.Config, svc.Namespace, pod.Name, pods.SyncContainerName, localRepoPath)
+		if err := applySyncHooks(connectService); err != nil {
+			return err
+		}
+		if err := connectService.SyncOnce(ctx); err != nil && err.Error() != "up_to_date" {
+			return fmt.Errorf("pre-attach sync failed: %w", err)
+		}
+
+		folder := pods.WorkspaceMountPath
+		if prefix, err := exec.Command("git", "rev-parse", "--show-prefix").Output(); err == nil {
+			folder = path.Join(folder, strings.TrimSpace(string(prefix)))
+		}
+
+		uri := makeCodeFolderURI(currentKubeContext(), svc.Namespace, pod.Name, pods.DevContainerName, folder)
+
+		if printCodeURI {
+			fmt.Println(uri)
+			return nil
+		}
+
+		say("🚀 Opening VS Code attached to %s...\n", pod.Name)
+		code := exec.Command("code", "--folder-uri", uri)
+		code.Stdout = os.Stdout
+		code.Stderr = os.Stderr
+		if err := code.Run(); err != nil {
+			return fmt.Errorf("failed to launch VS Code (is 'code' on your PATH?), open this URI manually: %s: %w", uri, err)
+		}
+		return nil
+	},
+}
+
+func makeCodeFolderURI(kubeContext, namespace, podName, containerName, folder string) string {
+	authority := "k8s-container"
+	if kubeContext != "" {
+		authority += "+context=" + kubeContext
+	}
+	authority += fmt.Sprintf("+podname=%s+namespace=%s+name=%s", podName, namespace, containerName)
+	return fmt.Sprintf("vscode-remote://%s%s", authority, folder)
+}
+
+func currentKubeContext() string {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	raw, err := rules.Load()
+	if err != nil {
+		return ""
+	}
+	return raw.CurrentContext
+}
+
+func init() {
+	rootCmd.AddCommand(codeCmd)
+	codeCmd.Flags().BoolVar(&printCodeURI, "print", false, "Print the VS Code folder URI instead of launching VS Code")
+}
@@ -0,0 +1,231 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/Quatton/qwex/apps/qwexctl/internal/batch"
+	"github.com/Quatton/qwex/apps/qwexctl/internal/connect"
+	"github.com/Quatton/qwex/apps/qwexctl/internal/pods"
+	"github.com/Quatton/qwex/apps/qwexctl/internal/telemetry"
+	"github.com/spf13/viper"
+)
+
+const (
+	projectConfigName = ".qwexctl.yaml"
+
+	devImageKey     = "images.dev"
+	gpuImageKey     = "images.gpu"
+	syncImageKey    = "images.sync"
+	batchImageKey   = "images.batch"
+	allowedImageKey = "images.allowed"
+
+	workspaceKey = "workspace"
+
+	syncInstallKey = "sync.install"
+	syncHooksKey   = "sync.hooks"
+
+	logsRedactKey = "logs.redact"
+
+	// A list of NAME=VALUE strings rather than a map, since viper lowercases map keys
+	envKey = "env"
+)
+
+const (
+	envSourceUser    = "user config"
+	envSourceProject = "project config"
+	envSourceFlag    = "--env"
+)
+
+// sourcedEnv is a job env var and where it was defined
+type sourcedEnv struct {
+	batch.EnvVar
+	Source string
+}
+
+func readConfigEnv(path, source string) ([]sourcedEnv, error) {
+	if path == "" {
+		return nil, nil
+	}
+	if _, err := os.Stat(path); err != nil {
+		return nil, nil
+	}
+
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, nil
+	}
+
+	var env []sourcedEnv
+	for _, value := range v.GetStringSlice(envKey) {
+		e, err := batch.ParseEnvVar(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s in %s: %w", envKey, path, err)
+		}
+		env = append(env, sourcedEnv{EnvVar: e, Source: source})
+	}
+	return env, nil
+}
+
+// effectiveEnv merges job env with precedence user config < project config < --env flags
+func effectiveEnv(flagValues []string) ([]sourcedEnv, error) {
+	var layers []sourcedEnv
+
+	userEnv, err := readConfigEnv(viper.ConfigFileUsed(), envSourceUser)
+	if err != nil {
+		return nil, err
+	}
+	layers = append(layers, userEnv...)
+
+	if cfgFile == "" {
+		projectEnv, err := readConfigEnv(filepath.Join(connect.GetLocalRepoPath(""), projectConfigName), envSourceProject)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, projectEnv...)
+	}
+
+	for _, value := range flagValues {
+		e, err := batch.ParseEnvVar(value)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, sourcedEnv{EnvVar: e, Source: envSourceFlag})
+	}
+
+	// Later layers override earlier ones but keep the first position
+	var merged []sourcedEnv
+	index := map[string]int{}
+	for _, e := range layers {
+		if i, ok := index[e.Name]; ok {
+			merged[i] = e
+			continue
+		}
+		index[e.Name] = len(merged)
+		merged = append(merged, e)
+	}
+	return merged, nil
+}
+
+// userOnlyKeys can't be set by a project config: a cloned repository shouldn't be
+// able to opt the user into sending data elsewhere, or widen the image allowlist
+// meant to constrain it. Projects can still pick images within the allowlist.
+var userOnlyKeys = []string{
+	telemetry.EnabledKey,
+	telemetry.EndpointKey,
+	telemetry.InstallKey,
+	allowedImageKey,
+}
+
+// deleteKey removes a dotted key like "telemetry.enabled" from nested settings
+func deleteKey(settings map[string]any, key string) {
+	parts := strings.Split(key, ".")
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := settings[part].(map[string]any)
+		if !ok {
+			return
+		}
+		settings = next
+	}
+	delete(settings, parts[len(parts)-1])
+}
+
+// mergeProjectConfig layers <repo root>/.qwexctl.yaml over the user config
+func mergeProjectConfig() {
+	projectConfig := filepath.Join(connect.GetLocalRepoPath(""), projectConfigName)
+	if _, err := os.Stat(projectConfig); err != nil {
+		return
+	}
+
+	// Read separately so ConfigFileUsed keeps pointing at the user config
+	project := viper.New()
+	project.SetConfigFile(projectConfig)
+	if err := project.ReadInConfig(); err != nil {
+		return
+	}
+	settings := project.AllSettings()
+	for _, key := range userOnlyKeys {
+		deleteKey(settings, key)
+	}
+	_ = viper.MergeConfigMap(settings)
+}
+
+func configuredImages() pods.Images {
+	images := pods.DefaultImages()
+	if image := viper.GetString(devImageKey); image != "" {
+		images.Dev = image
+	}
+	if image := viper.GetString(gpuImageKey); image != "" {
+		images.GPU = image
+	}
+	if image := viper.GetString(syncImageKey); image != "" {
+		images.Sync = image
+	}
+	return images
+}
+
+func validateImages(images ...string) error {
+	allowed := viper.GetStringSlice(allowedImageKey)
+	for _, image := range images {
+		if err := pods.ValidateImage(image, allowed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newPodService(svc *Service) (*pods.Service, error) {
+	images := configuredImages()
+	if err := validateImages(images.Dev, images.GPU, images.Sync); err != nil {
+		return nil, err
+	}
+
+	workspace := viper.GetString(workspaceKey)
+	if workspace != "" {
+		if err := pods.ValidateWorkspaceName(workspace); err != nil {
+			return nil, err
+		}
+	}
+
+	podService := pods.NewService(svc.K8s.Clientset, svc.Namespace)
+	podService.Images = images
+	podService.Workspace = workspace
+	return podService, nil
+}
+
+// batchImage resolves the batch image: flag > config > default
+func batchImage(flagValue string) (string, error) {
+	image := flagValue
+	if image == "" {
+		image = viper.GetString(batchImageKey)
+	}
+	if image == "" {
+		image = batch.DemoImage
+	}
+	return image, validateImages(image)
+}
+
+// applySyncHooks enables dependency installs after sync: sync.hooks wins over sync.install defaults
+func applySyncHooks(connectService *connect.Service) error {
+	var hooks []connect.SyncHook
+	if viper.IsSet(syncHooksKey) {
+		if err := viper.UnmarshalKey(syncHooksKey, &hooks); err != nil {
+			return fmt.Errorf("invalid %s config: %w", syncHooksKey, err)
+		}
+	} else if viper.GetBool(syncInstallKey) {
+		hooks = connect.DefaultSyncHooks()
+	}
+
+	connectService.SyncHooks = hooks
+	connectService.HookContainerName = pods.DevContainerName
+	return nil
+}
+
+// redactPatterns returns the extra log redaction regexes from logs.redact
+func redactPatterns() ([]*regexp.Regexp, error) {
+	return batch.ParseRedactPatterns(viper.GetStringSlice(logsRedactKey))
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"text/tabwriter"
+
+	"github.com/Quatton/qwex/apps/qwexctl/internal/batch"
+	"github.com/Quatton/qwex/apps/qwexctl/internal/connect"
+	"github.com/spf13/cobra"
+)
+
+var diffAll bool
+
+var diffCmd = &cobra.Command{
+	Use:   "diff [run-id-a] [run-id-b]",
+	Short: "Show what changed between two batch runs",
+	Long: `Compare two batch runs side by side: image, command, resources, env (values
+redacted), and the git diff stat between the commits they ran.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		svc := cmd.Context().Value("service").(*Service)
+		ctx := cmd.Context()
+
+		localRepoPath := connect.GetLocalRepoPath(cfgFile)
+		connectService := connect.NewService(svc.K8s.Clientset, svc.K8s.Config, svc.Namespace, "", "", localRepoPath)
+		batchService := batch.NewService(connectService, "", "", nil, nil, "", "")
+
+		jobA, err := batchService.GetRunJob(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		jobB, err := batchService.GetRunJob(ctx, args[1])
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "FIELD\tA\tB\t")
+		for _, d := range batch.DiffRuns(jobA, jobB) {
+			if !d.Changed && !diffAll {
+				continue
+			}
+			marker := ""
+			if d.Changed {
+				marker = "*"
+			}
+			fmt.Fprintf(w, "%s%s\t%s\t%s\t\n", marker, d.Field, d.A, d.B)
+		}
+		w.Flush()
+
+		shaA, shaB := jobA.Labels["qwex.dev/sha"], jobB.Labels["qwex.dev/sha"]
+		if shaA == shaB {
+			return nil
+		}
+
+		fmt.Println()
+		gitDiff := exec.Command("git", "-C", localRepoPath, "diff", "--stat", shaA, shaB)
+		gitDiff.Stdout = os.Stdout
+		gitDiff.Stderr = os.Stderr
+		if err := gitDiff.Run(); err != nil {
+			fmt.Printf("Could not diff commits %s..%s locally: %v\n", shaA, shaB, err)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+	diffCmd.Flags().BoolVarP(&diffAll, "all", "a", false, "Show unchanged fields too")
+}
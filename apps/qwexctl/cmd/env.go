@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var envFlagValues []string
+
+var envCmd = &cobra.Command{
+	Use:   "env",
+	Short: "Show the default environment batch jobs get and where each value comes from",
+	Long: `Print the job environment after merging, lowest precedence first:
+'env' in the user config, 'env' in the project .qwexctl.yaml, then --env flags.
+Values are shown unrendered; templates are filled in at submit time.`,
+	Args: cobra.NoArgs,
+	// Doesn't need a cluster, so skip the root service setup
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		env, err := effectiveEnv(envFlagValues)
+		if err != nil {
+			return err
+		}
+
+		if len(env) == 0 {
+			fmt.Println("No default env configured")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tVALUE\tSOURCE")
+		for _, e := range env {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", e.Name, e.Value, e.Source)
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(envCmd)
+	envCmd.Flags().StringArrayVarP(&envFlagValues, "env", "e", nil, "Preview the effect of NAME=VALUE as passed to batch --env")
+}
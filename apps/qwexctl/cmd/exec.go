@@ -46,7 +46,11 @@ var execCmd = &cobra.Command{
 		svc := cmd.Context().Value("service").(*Service)
 		ctx := cmd.Context()
 
-		podService := &pods.Service{K8s: svc.K8s.Clientset, Namespace: svc.Namespace}
+		podService, err := newPodService(svc)
+		if err != nil {
+			return err
+		}
+
 		dep, err := podService.GetOrCreateDevelopmentDeployment(ctx, pods.Active)
 		if err != nil {
 			return err
@@ -58,6 +62,9 @@ var execCmd = &cobra.Command{
 		}
 
 		connectService := connect.NewService(svc.K8s.Clientset, svc.K8s.Config, namespace, pod.Name, pods.SyncContainerName, localRepoPath)
+		if err := applySyncHooks(connectService); err != nil {
+			return err
+		}
 
 		ctx, cancel := context.WithCancel(cmd.Context())
 		defer cancel()
@@ -257,6 +264,10 @@ func watchRecursive(watcher *fsnotify.Watcher, dir string, parentMatchers []giti
 		}
 	}
 
+	if m := connect.ReadIgnoreFile(dir, connect.IgnoreFileName); m != nil {
+		matchers = append(matchers, m)
+	}
+
 	entries, err := os.ReadDir(dir)
 	if err != nil {
 		return nil
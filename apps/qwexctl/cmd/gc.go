@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/Quatton/qwex/apps/qwexctl/internal/batch"
+	"github.com/Quatton/qwex/apps/qwexctl/internal/connect"
+	"github.com/spf13/cobra"
+)
+
+var gcDelete bool
+
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Find and clean up leftover batch jobs, pods and scripts",
+	Long: `Report qwex-created resources that outlived their run: finished jobs the
+TTL controller never removed, queued runs whose submitter was killed,
+batch pods whose job is gone, and uploaded scripts whose job was never
+created. Pass --delete to remove them.
+Workspace volumes are never touched; use 'qwexctl workspace' for those.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		svc := cmd.Context().Value("service").(*Service)
+		ctx := cmd.Context()
+
+		localRepoPath := connect.GetLocalRepoPath(cfgFile)
+		connectService := connect.NewService(svc.K8s.Clientset, svc.K8s.Config, svc.Namespace, "", "", localRepoPath)
+		batchService := batch.NewService(connectService, "", "", nil, nil, "", "")
+
+		orphans, err := batchService.FindOrphans(ctx)
+		if err != nil {
+			return err
+		}
+		if len(orphans) == 0 {
+			say("✨ Nothing to clean up\n")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "KIND\tNAME\tREASON")
+		for _, o := range orphans {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", o.Kind, o.Name, o.Reason)
+		}
+		w.Flush()
+
+		if !gcDelete {
+			say("\n💡 Run 'qwexctl gc --delete' to remove %d resource(s)\n", len(orphans))
+			return nil
+		}
+
+		failed := 0
+		for _, o := range orphans {
+			if err := batchService.DeleteOrphan(ctx, o); err != nil {
+				fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+				failed++
+			}
+		}
+		say("🧹 Removed %d of %d resource(s)\n", len(orphans)-failed, len(orphans))
+		if failed > 0 {
+			return fmt.Errorf("failed to remove %d resource(s)", failed)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(gcCmd)
+	gcCmd.Flags().BoolVar(&gcDelete, "delete", false, "Delete what was found instead of only reporting it")
+}
@@ -12,17 +12,25 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var followLogs bool
+var (
+	followLogs bool
+	logsRange  batch.LogRange
+)
 
 var logsCmd = &cobra.Command{
 	Use:   "logs [run-id]",
 	Short: "View logs for a batch job run",
 	Long: `View logs for a specific batch job run by its run-id.
-Use -f/--follow to stream logs in real-time.`,
+Use -f/--follow to stream logs in real-time, and --tail, --since or
+--limit-bytes to read only part of a large log.`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		runID := args[0]
 
+		if err := logsRange.Validate(); err != nil {
+			return withExitCode(exitUsage, err)
+		}
+
 		localRepoPath := connect.GetLocalRepoPath(cfgFile)
 
 		svc, err := initServiceManual()
@@ -31,7 +39,11 @@ Use -f/--follow to stream logs in real-time.`,
 		}
 		ctx := cmd.Context()
 
-		podService := &pods.Service{K8s: svc.K8s.Clientset, Namespace: svc.Namespace}
+		podService, err := newPodService(svc)
+		if err != nil {
+			return err
+		}
+
 		dep, err := podService.GetOrCreateDevelopmentDeployment(ctx, pods.Active)
 		if err != nil {
 			return err
@@ -45,22 +57,30 @@ Use -f/--follow to stream logs in real-time.`,
 		connectService := connect.NewService(svc.K8s.Clientset, svc.K8s.Config, namespace, pod.Name, pods.SyncContainerName, localRepoPath)
 
 		batchService := batch.NewService(connectService, "", "", nil, nil, "", "")
+		batchService.LogRange = logsRange
 
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		batchService.RedactPatterns, err = redactPatterns()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
 		defer cancel()
 
 		if followLogs {
-			fmt.Printf("📋 Following logs for run: %s\n", runID)
-			if err := batchService.FollowRunLogs(ctx, runID, os.Stdout); err != nil {
+			say("📋 Following logs for run: %s\n", runID)
+			status := newStatusLine(os.Stdout)
+			batchService.OnWait = status.Update
+			err := batchService.FollowRunLogs(ctx, runID, status)
+			status.Stop()
+			if err != nil {
 				return fmt.Errorf("failed to follow logs: %w", err)
 			}
 		} else {
-			fmt.Printf("📋 Fetching logs for run: %s\n", runID)
-			logs, err := batchService.GetRunLogs(ctx, runID)
-			if err != nil {
+			say("📋 Fetching logs for run: %s\n", runID)
+			if err := batchService.WriteRunLogs(ctx, runID, os.Stdout); err != nil {
 				return fmt.Errorf("failed to get logs: %w", err)
 			}
-			fmt.Print(logs)
 		}
 
 		return nil
@@ -70,4 +90,7 @@ Use -f/--follow to stream logs in real-time.`,
 func init() {
 	rootCmd.AddCommand(logsCmd)
 	logsCmd.Flags().BoolVarP(&followLogs, "follow", "f", false, "Follow log output in real-time")
+	logsCmd.Flags().Int64Var(&logsRange.TailLines, "tail", 0, "Only show the last N lines")
+	logsCmd.Flags().DurationVar(&logsRange.Since, "since", 0, "Only show lines newer than this, e.g. 10m")
+	logsCmd.Flags().Int64Var(&logsRange.LimitBytes, "limit-bytes", 0, "Stop after this many bytes")
 }
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Quatton/qwex/apps/qwexctl/internal/batch"
+	"github.com/Quatton/qwex/apps/qwexctl/internal/connect"
+	"github.com/spf13/cobra"
+)
+
+var logsDiffContext int
+
+var logsDiffCmd = &cobra.Command{
+	Use:   "diff [good-run-id] [bad-run-id]",
+	Short: "Diff the logs of a run against a reference run",
+	Long: `Align and diff the logs of two runs after normalizing them: timestamps and
+hex ids are stripped and numbers are bucketed by order of magnitude, so only
+meaningful differences remain. The first point of divergence is highlighted.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		svc, err := initServiceManual()
+		if err != nil {
+			return err
+		}
+		ctx := cmd.Context()
+
+		localRepoPath := connect.GetLocalRepoPath(cfgFile)
+		connectService := connect.NewService(svc.K8s.Clientset, svc.K8s.Config, svc.Namespace, "", "", localRepoPath)
+		batchService := batch.NewService(connectService, "", "", nil, nil, "", "")
+
+		batchService.RedactPatterns, err = redactPatterns()
+		if err != nil {
+			return err
+		}
+
+		goodLogs, err := batchService.GetRunLogs(ctx, args[0])
+		if err != nil {
+			return fmt.Errorf("failed to get logs for %s: %w", args[0], err)
+		}
+		badLogs, err := batchService.GetRunLogs(ctx, args[1])
+		if err != nil {
+			return fmt.Errorf("failed to get logs for %s: %w", args[1], err)
+		}
+
+		diff := batch.DiffLogs(splitLogLines(goodLogs), splitLogLines(badLogs))
+		first := batch.FirstDivergence(diff)
+		if first < 0 {
+			fmt.Println("✅ Logs match after normalization")
+			return nil
+		}
+
+		at := diff[first]
+		fmt.Printf("🔍 First divergence: line %d of %s, line %d of %s\n\n", max(at.LineA, 1), args[0], max(at.LineB, 1), args[1])
+		fmt.Printf("--- %s\n+++ %s\n", args[0], args[1])
+
+		// Print changed lines with surrounding context, separating distant hunks
+		last := -1
+		for i, line := range diff {
+			if line.Op == batch.LogEqual && !nearChange(diff, i, logsDiffContext) {
+				continue
+			}
+			if last >= 0 && i > last+1 {
+				fmt.Println("...")
+			}
+			fmt.Printf("%c %s\n", line.Op, line.Text)
+			last = i
+		}
+		return nil
+	},
+}
+
+func splitLogLines(logs string) []string {
+	logs = strings.TrimSuffix(logs, "\n")
+	if logs == "" {
+		return nil
+	}
+	return strings.Split(logs, "\n")
+}
+
+func nearChange(diff []batch.LogDiffLine, i, context int) bool {
+	for j := max(i-context, 0); j <= min(i+context, len(diff)-1); j++ {
+		if diff[j].Op != batch.LogEqual {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	logsCmd.AddCommand(logsDiffCmd)
+	logsDiffCmd.Flags().IntVarP(&logsDiffContext, "context", "C", 3, "Lines of context around each difference")
+}
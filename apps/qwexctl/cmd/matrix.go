@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Quatton/qwex/apps/qwexctl/internal/batch"
+	"github.com/google/uuid"
+	v1 "k8s.io/api/batch/v1"
+)
+
+// How often runMatrix checks on its runs
+var matrixPollInterval = 5 * time.Second
+
+type matrixRun struct {
+	params []batch.EnvVar
+	runID  string
+	job    *v1.Job
+	err    error
+}
+
+// runMatrix submits one run per combination, at most maxParallel active at once,
+// and waits for all of them; it fails if any run fails
+func runMatrix(ctx context.Context, base *batch.Service, combos [][]batch.EnvVar, maxParallel int) error {
+	matrixID := uuid.New().String()[:8]
+	say("🧮 Matrix %s: %d run(s) (cancel with: qwexctl runs cancel -l %s=%s --all)\n", matrixID, len(combos), batch.MatrixLabel, matrixID)
+
+	runs := make([]*matrixRun, len(combos))
+	for i, params := range combos {
+		runs[i] = &matrixRun{params: params}
+	}
+
+	table := &matrixTable{tty: isInteractive()}
+	next := 0
+	for {
+		active, failed, done := 0, 0, 0
+		for _, r := range runs[:next] {
+			switch {
+			case r.err != nil:
+				failed++
+				done++
+			case r.job == nil || !batch.IsJobFinished(r.job):
+				active++
+			case batch.IsJobSucceeded(r.job):
+				done++
+			default:
+				failed++
+				done++
+			}
+		}
+
+		for next < len(runs) && (maxParallel <= 0 || active < maxParallel) {
+			r := runs[next]
+			r.job, r.err = submitMatrixRun(ctx, base, r.params, matrixID)
+			if r.job != nil {
+				r.runID = r.job.Labels[batch.RunIDLabel]
+			}
+			next++
+			active++
+		}
+
+		if !quiet {
+			table.render(runs[:next], len(runs))
+		}
+		if done == len(runs) {
+			if quiet {
+				for _, r := range runs {
+					fmt.Println(r.runID, matrixStatus(r))
+				}
+			}
+			if failed > 0 {
+				return withExitCode(exitRunFailed, fmt.Errorf("%d of %d matrix run(s) failed", failed, len(runs)))
+			}
+			say("✅ All %d matrix run(s) succeeded\n", len(runs))
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(matrixPollInterval):
+		}
+
+		for _, r := range runs[:next] {
+			if r.err != nil || r.job == nil || batch.IsJobFinished(r.job) {
+				continue
+			}
+			job, err := base.GetRunJob(ctx, r.runID)
+			var notFound *batch.RunNotFoundError
+			switch {
+			case errors.As(err, &notFound):
+				r.err = fmt.Errorf("run was cancelled or deleted before it finished")
+			case err != nil:
+				// Likely transient; ask again on the next poll
+			case job.DeletionTimestamp != nil:
+				r.err = fmt.Errorf("run was cancelled before it finished")
+			default:
+				r.job = job
+			}
+		}
+	}
+}
+
+func submitMatrixRun(ctx context.Context, base *batch.Service, params []batch.EnvVar, matrixID string) (*v1.Job, error) {
+	child := *base
+	child.Env = append(append([]batch.EnvVar{}, base.Env...), params...)
+	child.Labels = maps.Clone(base.Labels)
+	if child.Labels == nil {
+		child.Labels = map[string]string{}
+	}
+	child.Labels[batch.MatrixLabel] = matrixID
+	return child.Submit(ctx)
+}
+
+// matrixTable redraws in place on a TTY, otherwise prints whenever a status changes
+type matrixTable struct {
+	tty   bool
+	lines int
+	last  string
+}
+
+func (t *matrixTable) render(runs []*matrixRun, total int) {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RUN ID\tPARAMS\tSTATUS\tDURATION")
+	for _, r := range runs {
+		status, duration := matrixStatus(r), "-"
+		if r.err == nil {
+			duration = formatDuration(batch.RunDuration(r.job))
+		}
+		runID := r.runID
+		if runID == "" {
+			runID = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", runID, batch.FormatParams(r.params), status, duration)
+		if r.err != nil {
+			fmt.Fprintf(w, "\t%v\t\t\n", r.err)
+		}
+	}
+	if pending := total - len(runs); pending > 0 {
+		fmt.Fprintf(w, "-\t%d more waiting for a slot\t\t\n", pending)
+	}
+	w.Flush()
+
+	out := buf.String()
+	if !t.tty {
+		// Durations tick every poll, so only compare the statuses
+		key := strings.Join(strings.Fields(stripDurations(runs)), " ")
+		if key == t.last {
+			return
+		}
+		t.last = key
+		fmt.Print(out)
+		return
+	}
+
+	if t.lines > 0 {
+		fmt.Printf("\033[%dA\033[J", t.lines)
+	}
+	fmt.Print(out)
+	t.lines = strings.Count(out, "\n")
+}
+
+func stripDurations(runs []*matrixRun) string {
+	var b strings.Builder
+	for _, r := range runs {
+		fmt.Fprintf(&b, "%s %s ", r.runID, matrixStatus(r))
+	}
+	return b.String()
+}
+
+func matrixStatus(r *matrixRun) string {
+	if r.err != nil {
+		return "Error"
+	}
+	return batch.JobStatus(r.job)
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Quatton/qwex/apps/qwexctl/internal/batch"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRunMatrixDeletedRun(t *testing.T) {
+	interval := matrixPollInterval
+	matrixPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { matrixPollInterval = interval })
+
+	client := fake.NewSimpleClientset()
+	base, _ := scriptService(t, client)
+	combos := [][]batch.EnvVar{{{Name: "LR", Value: "0.1"}}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	result := make(chan error, 1)
+	go func() {
+		result <- runMatrix(ctx, base, combos, 0)
+	}()
+
+	// Cancel the run once it has been submitted
+	jobs := client.BatchV1().Jobs("ns")
+	for {
+		list, err := jobs.List(ctx, metav1.ListOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list.Items) > 0 {
+			if err := jobs.Delete(ctx, list.Items[0].Name, metav1.DeleteOptions{}); err != nil {
+				t.Fatal(err)
+			}
+			break
+		}
+		if ctx.Err() != nil {
+			t.Fatal("the matrix run was never submitted")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	err := <-result
+	if err == nil || ctx.Err() != nil {
+		t.Fatalf("runMatrix() = %v, want a failed matrix", err)
+	}
+	if code := exitCode(err); code != exitRunFailed {
+		t.Errorf("exit code = %d, want %d", code, exitRunFailed)
+	}
+}
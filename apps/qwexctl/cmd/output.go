@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/Quatton/qwex/apps/qwexctl/internal/batch"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes are part of the CLI contract for scripts; don't renumber them
+const (
+	exitError     = 1 // anything not covered below
+	exitUsage     = 2 // bad flags or arguments
+	exitRunFailed = 3 // a followed run (or any matrix run) failed, or broke its scratch size or contract
+	exitPreflight = 4 // --strict preflight checks found problems
+)
+
+var quiet bool
+
+// jsonOutput is set by commands with --output json: stdout then only carries
+// the JSON result, and progress for humans moves to stderr
+var jsonOutput bool
+
+type codedError struct {
+	code int
+	err  error
+}
+
+func (e *codedError) Error() string { return e.err.Error() }
+func (e *codedError) Unwrap() error { return e.err }
+
+func withExitCode(code int, err error) error {
+	return &codedError{code: code, err: err}
+}
+
+func exitCode(err error) int {
+	var coded *codedError
+	if errors.As(err, &coded) {
+		return coded.code
+	}
+	var runFailed *batch.RunFailedError
+	if errors.As(err, &runFailed) {
+		return exitRunFailed
+	}
+	return exitError
+}
+
+// wrapArgsValidators makes positional argument errors (ExactArgs, NoArgs, ...)
+// exit with exitUsage, like flag errors do
+func wrapArgsValidators(cmd *cobra.Command) {
+	if validate := cmd.Args; validate != nil {
+		cmd.Args = func(cmd *cobra.Command, args []string) error {
+			if err := validate(cmd, args); err != nil {
+				return withExitCode(exitUsage, err)
+			}
+			return nil
+		}
+	}
+	for _, child := range cmd.Commands() {
+		wrapArgsValidators(child)
+	}
+}
+
+// say prints progress for humans; --quiet drops it so stdout only carries results
+func say(format string, args ...any) {
+	switch {
+	case quiet:
+	case jsonOutput:
+		fmt.Fprintf(os.Stderr, format, args...)
+	default:
+		fmt.Printf(format, args...)
+	}
+}
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Quatton/qwex/apps/qwexctl/internal/batch"
+)
+
+func TestExitCode(t *testing.T) {
+	runFailed := &batch.RunFailedError{Reason: batch.ContractViolation, Message: "missing output model.pt"}
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"plain error", fmt.Errorf("boom"), exitError},
+		{"coded error", withExitCode(exitPreflight, fmt.Errorf("warnings")), exitPreflight},
+		{"run failure", runFailed, exitRunFailed},
+		{"wrapped run failure", fmt.Errorf("failed to follow logs: %w", runFailed), exitRunFailed},
+	}
+	for _, tt := range tests {
+		if got := exitCode(tt.err); got != tt.want {
+			t.Errorf("%s: exitCode() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
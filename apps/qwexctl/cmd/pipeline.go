@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Quatton/qwex/apps/qwexctl/internal/batch"
+	"github.com/Quatton/qwex/apps/qwexctl/internal/connect"
+	"github.com/Quatton/qwex/apps/qwexctl/internal/pods"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/batch/v1"
+)
+
+var pipelineCmd = &cobra.Command{
+	Use:   "pipeline [job|all]",
+	Short: "Run a job from the project's jobs: section along with the jobs it needs",
+	Long: `Run named jobs defined in the project config (.qwexctl.yaml at the repo root).
+Each job takes the same fields as a batch --file job file, plus needs:
+
+  jobs:
+    prepare:
+      command: [python, prepare.py]
+    train:
+      command: [python, train.py]
+      needs: [prepare]
+      resources: {gpu: 1}
+
+"qwexctl pipeline train" runs prepare, then train; "all" runs every job.
+Jobs run one at a time as batch runs, each after the jobs it needs succeed.
+Runs are labelled with the pipeline id and job name, so they can be listed
+or cancelled together with -l qwex.dev/pipeline=<id>.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		svc := ctx.Value("service").(*Service)
+
+		steps, err := batch.LoadPipeline(filepath.Join(connect.GetLocalRepoPath(""), projectConfigName))
+		if err != nil {
+			return err
+		}
+		order, err := batch.PipelineOrder(steps, args[0])
+		if err != nil {
+			return withExitCode(exitUsage, err)
+		}
+
+		podService, err := newPodService(svc)
+		if err != nil {
+			return err
+		}
+		dep, err := podService.GetOrCreateDevelopmentDeployment(ctx, pods.Active)
+		if err != nil {
+			return err
+		}
+		pod, err := podService.GetPodFromDeployment(ctx, dep)
+		if err != nil {
+			return err
+		}
+		connectService := connect.NewService(svc.K8s.Clientset, svc.K8s.Config, namespace, pod.Name, pods.SyncContainerName, connect.GetLocalRepoPath(cfgFile))
+
+		redact, err := redactPatterns()
+		if err != nil {
+			return err
+		}
+
+		pipelineID := uuid.New().String()[:8]
+		say("🪜 Pipeline %s: %s\n", pipelineID, strings.Join(order, " → "))
+
+		jobs := map[string]*v1.Job{}
+		var failed error
+		for _, name := range order {
+			batchService, err := newStepService(connectService, podService, steps[name], name, pipelineID)
+			if err != nil {
+				return fmt.Errorf("job %s: %w", name, err)
+			}
+			batchService.RedactPatterns = redact
+
+			say("\n▶️  %s\n", name)
+			job, err := batchService.Submit(ctx)
+			if err != nil {
+				failed = fmt.Errorf("job %s: %w", name, err)
+				break
+			}
+			runID := job.Labels[batch.RunIDLabel]
+			jobs[name] = job
+
+			if !batch.IsJobSucceeded(job) {
+				if err := batchService.FollowRunLogs(ctx, runID, os.Stdout); err != nil {
+					fmt.Fprintf(os.Stderr, "Error following logs: %v\n", err)
+				}
+				if finished, err := batchService.WaitForRunFinished(ctx, runID, 30*time.Second); err == nil {
+					jobs[name] = finished
+				}
+			}
+			if !batch.IsJobSucceeded(jobs[name]) {
+				failed = withExitCode(exitRunFailed, fmt.Errorf("job %s (run %s) did not succeed, stopping the pipeline", name, runID))
+				break
+			}
+		}
+
+		printPipelineSummary(order, jobs)
+		return failed
+	},
+}
+
+// newStepService builds the batch run for one pipeline job, the way batch --file would
+func newStepService(connectService *connect.Service, podService *pods.Service, step batch.PipelineStep, name, pipelineID string) (*batch.Service, error) {
+	targetImage, err := batchImage(step.Image)
+	if err != nil {
+		return nil, err
+	}
+
+	merged, err := effectiveEnv(step.EnvValues())
+	if err != nil {
+		return nil, err
+	}
+	var env []batch.EnvVar
+	for _, e := range merged {
+		env = append(env, e.EnvVar)
+	}
+
+	labels, err := batch.ParseLabels(step.LabelValues())
+	if err != nil {
+		return nil, err
+	}
+	labels[batch.PipelineLabel] = pipelineID
+	labels[batch.StepLabel] = name
+
+	scratch, err := batch.ParseScratchSize(step.Scratch)
+	if err != nil {
+		return nil, err
+	}
+
+	maxDuration, err := step.Duration()
+	if err != nil {
+		return nil, err
+	}
+	grace, err := step.Grace()
+	if err != nil {
+		return nil, err
+	}
+
+	jobName := step.Name
+	if jobName == "" {
+		jobName = name
+	}
+
+	batchService := batch.NewService(connectService, "", targetImage, step.Command[:1], step.Command[1:], batch.BatchWorkDir, jobName)
+	batchService.SyncImage = podService.Images.Sync
+	batchService.Workspace = podService.Workspace
+	batchService.Inputs = step.Inputs
+	batchService.Env = env
+	batchService.Labels = labels
+	batchService.Scratch = scratch
+	batchService.Resources = step.Resources
+	batchService.MaxDuration = maxDuration
+	batchService.TerminationGrace = grace
+	if !step.Expect.Empty() {
+		batchService.Contract = step.Expect
+	}
+	return batchService, nil
+}
+
+func printPipelineSummary(order []string, jobs map[string]*v1.Job) {
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "JOB\tRUN ID\tSTATUS\tDURATION")
+	for _, name := range order {
+		job, ok := jobs[name]
+		if !ok {
+			fmt.Fprintf(w, "%s\t-\tSkipped\t-\n", name)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", name, job.Labels[batch.RunIDLabel], batch.JobStatus(job), formatDuration(batch.RunDuration(job)))
+	}
+	w.Flush()
+}
+
+func init() {
+	rootCmd.AddCommand(pipelineCmd)
+}
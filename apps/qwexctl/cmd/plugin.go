@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// Executables named qwexctl-<name> on PATH run as `qwexctl <name>`, like kubectl plugins
+const pluginPrefix = "qwexctl-"
+
+var pluginCmd = &cobra.Command{
+	Use:   "plugin",
+	Short: "Work with qwexctl plugins",
+	Long: `Any executable on PATH named qwexctl-<name> can be run as "qwexctl <name>".
+Dashes nest, so qwexctl-sweep-report runs as "qwexctl sweep report".
+
+Plugins receive their arguments unchanged, plus these environment variables:
+
+  QWEX_NAMESPACE     the namespace qwexctl would use
+  QWEX_WORKSPACE     the current dev workspace, if any
+  QWEX_CONFIG_FILE   the config file qwexctl loaded, if any
+  QWEX_BIN           the path of the qwexctl binary that ran the plugin
+
+Built-in commands always take precedence over plugins.`,
+}
+
+var pluginListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List plugins found on PATH",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		plugins := listPlugins()
+		if len(plugins) == 0 {
+			fmt.Println("No plugins found on PATH")
+			return nil
+		}
+
+		seen := map[string]bool{}
+		for _, path := range plugins {
+			name := pluginName(path)
+			switch {
+			case seen[name]:
+				fmt.Printf("%s (shadowed by an earlier PATH entry)\n", path)
+			case isBuiltinCommand(strings.Split(name, "-")):
+				fmt.Printf("%s (shadowed by the built-in command)\n", path)
+			default:
+				fmt.Println(path)
+			}
+			seen[name] = true
+		}
+		return nil
+	},
+}
+
+func pluginName(path string) string {
+	name := strings.TrimPrefix(filepath.Base(path), pluginPrefix)
+	if runtime.GOOS == "windows" {
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	return name
+}
+
+// listPlugins returns every plugin executable in PATH order
+func listPlugins() []string {
+	var plugins []string
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasPrefix(entry.Name(), pluginPrefix) {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			if _, err := exec.LookPath(path); err == nil {
+				plugins = append(plugins, path)
+			}
+		}
+	}
+	return plugins
+}
+
+func isBuiltinCommand(args []string) bool {
+	if slices.Contains([]string{"help", "completion", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd}, args[0]) {
+		return true
+	}
+	found, _, err := rootCmd.Find(args)
+	return err == nil && found != rootCmd
+}
+
+// findPlugin resolves leading args to the most specific plugin, returning its path and remaining args
+func findPlugin(args []string) (string, []string, bool) {
+	var parts []string
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			break
+		}
+		parts = append(parts, arg)
+	}
+
+	for n := len(parts); n > 0; n-- {
+		path, err := exec.LookPath(pluginPrefix + strings.Join(parts[:n], "-"))
+		if err == nil {
+			return path, args[n:], true
+		}
+	}
+	return "", nil, false
+}
+
+// runPlugin runs a plugin if args name one and not a built-in command
+func runPlugin(args []string) (bool, error) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") || isBuiltinCommand(args) {
+		return false, nil
+	}
+	path, rest, ok := findPlugin(args)
+	if !ok {
+		return false, nil
+	}
+
+	initConfig()
+	self, _ := os.Executable()
+
+	plugin := exec.Command(path, rest...)
+	plugin.Stdin = os.Stdin
+	plugin.Stdout = os.Stdout
+	plugin.Stderr = os.Stderr
+	plugin.Env = append(os.Environ(),
+		"QWEX_NAMESPACE="+viper.GetString("namespace"),
+		"QWEX_WORKSPACE="+viper.GetString(workspaceKey),
+		"QWEX_CONFIG_FILE="+viper.ConfigFileUsed(),
+		"QWEX_BIN="+self,
+	)
+
+	err := plugin.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		// The plugin already reported its own error
+		os.Exit(exitErr.ExitCode())
+	}
+	if err != nil {
+		return true, fmt.Errorf("failed to run plugin %s: %w", path, err)
+	}
+	return true, nil
+}
+
+func init() {
+	rootCmd.AddCommand(pluginCmd)
+	pluginCmd.AddCommand(pluginListCmd)
+}
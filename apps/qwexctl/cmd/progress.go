@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Quatton/qwex/apps/qwexctl/internal/batch"
+	"golang.org/x/term"
+	v1 "k8s.io/api/batch/v1"
+)
+
+var spinnerFrames = []rune("⠋⠙⠹⠸⠼⠴⠦⠧⠇⠏")
+
+// statusLine shows a spinner with the run's phase on a TTY, or one line per phase change otherwise.
+// Used as the log writer, it keeps the spinner pinned below the log tail on a TTY.
+type statusLine struct {
+	mu      sync.Mutex
+	phase   string
+	start   time.Time
+	tty     bool
+	out     io.Writer
+	logs    io.Writer
+	partial []byte
+	frame   int
+	stopped bool
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+func isInteractive() bool {
+	return term.IsTerminal(int(os.Stdout.Fd())) && os.Getenv("CI") == ""
+}
+
+// newStatusLine reports progress until Stop; log output is written through it to logs.
+// With --output json, stdout only carries the result, so progress goes to stderr as plain lines.
+func newStatusLine(logs io.Writer) *statusLine {
+	s := &statusLine{start: time.Now(), tty: isInteractive() && !jsonOutput, out: os.Stdout, logs: logs, done: make(chan struct{})}
+	if jsonOutput {
+		s.out = os.Stderr
+	}
+	if quiet {
+		// Never started, so every update is dropped
+		s.stopped = true
+		return s
+	}
+	if s.tty {
+		s.wg.Add(1)
+		go s.spin()
+	}
+	return s
+}
+
+func (s *statusLine) spin() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			fmt.Fprint(s.out, "\r\033[2K")
+			return
+		case <-ticker.C:
+		}
+		s.mu.Lock()
+		s.frame++
+		s.draw()
+		s.mu.Unlock()
+	}
+}
+
+// draw redraws the spinner line; callers hold s.mu
+func (s *statusLine) draw() {
+	if s.phase == "" {
+		return
+	}
+	elapsed := time.Since(s.start).Round(time.Second)
+	fmt.Fprintf(s.out, "\r\033[2K%c %s (%s)", spinnerFrames[s.frame%len(spinnerFrames)], s.phase, elapsed)
+}
+
+func (s *statusLine) Update(phase string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped || phase == s.phase {
+		return
+	}
+	s.phase = phase
+	if !s.tty {
+		fmt.Fprintf(s.out, "⏳ %s\n", phase)
+	}
+}
+
+// Write passes log output on. On a TTY, complete lines are printed above the
+// spinner, which keeps showing the elapsed time; elsewhere, or once the job
+// draws its own progress bars with bare carriage returns, the status line stops.
+func (s *statusLine) Write(p []byte) (int, error) {
+	if !s.tty || hasBareCR(p) {
+		s.Stop()
+		return s.logs.Write(p)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return s.logs.Write(p)
+	}
+
+	s.phase = "running"
+	s.partial = append(s.partial, p...)
+	end := bytes.LastIndexByte(s.partial, '\n')
+	if end < 0 {
+		return len(p), nil
+	}
+	fmt.Fprint(s.out, "\r\033[2K")
+	if _, err := s.logs.Write(s.partial[:end+1]); err != nil {
+		return 0, err
+	}
+	s.partial = append([]byte(nil), s.partial[end+1:]...)
+	s.draw()
+	return len(p), nil
+}
+
+func hasBareCR(p []byte) bool {
+	return bytes.Contains(bytes.ReplaceAll(p, []byte("\r\n"), nil), []byte("\r"))
+}
+
+// Stop clears the spinner and writes out any unfinished log line
+func (s *statusLine) Stop() {
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		return
+	}
+	s.stopped = true
+	s.mu.Unlock()
+
+	close(s.done)
+	s.wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.partial) > 0 {
+		s.logs.Write(s.partial)
+		s.partial = nil
+	}
+}
+
+// runResult is a run as --output json prints it
+type runResult struct {
+	RunID           string  `json:"run_id"`
+	Job             string  `json:"job"`
+	Status          string  `json:"status"`
+	Smoke           bool    `json:"smoke,omitempty"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	Error           string  `json:"error,omitempty"`
+}
+
+func printRunJSON(job *v1.Job, failure error) error {
+	result := runResult{
+		RunID:           job.Labels[batch.RunIDLabel],
+		Job:             job.Name,
+		Status:          batch.JobStatus(job),
+		Smoke:           batch.IsSmokeRun(job.Labels),
+		DurationSeconds: batch.RunDuration(job).Round(time.Second).Seconds(),
+	}
+	if failure != nil {
+		result.Error = failure.Error()
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(result)
+}
+
+func printRunSummary(job *v1.Job) {
+	status := batch.JobStatus(job)
+	icon := "✅"
+	switch status {
+	case "Failed":
+		icon = "❌"
+	case "Running", "Pending":
+		icon = "⏳"
+	}
+
+	lines := []string{
+		fmt.Sprintf("status:   %s", status),
+		fmt.Sprintf("run-id:   %s", job.Labels[batch.RunIDLabel]),
+		fmt.Sprintf("job:      %s", job.Name),
+		fmt.Sprintf("duration: %s", formatDuration(batch.RunDuration(job))),
+	}
+
+	width := 0
+	for _, l := range lines {
+		width = max(width, len([]rune(l)))
+	}
+	border := make([]rune, width+2)
+	for i := range border {
+		border[i] = '─'
+	}
+
+	fmt.Printf("\n%s Run %s\n┌%s┐\n", icon, strings.ToLower(status), string(border))
+	for _, l := range lines {
+		fmt.Printf("│ %-*s │\n", width, l)
+	}
+	fmt.Printf("└%s┘\n", string(border))
+}
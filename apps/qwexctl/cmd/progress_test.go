@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestStatusLineLogTail(t *testing.T) {
+	var out, logs bytes.Buffer
+	s := &statusLine{tty: true, out: &out, logs: &logs, phase: "pulling image", done: make(chan struct{})}
+
+	s.Write([]byte("epoch 1\nepo"))
+	if logs.String() != "epoch 1\n" {
+		t.Errorf("expected only complete lines to be written, got %q", logs.String())
+	}
+	if !strings.Contains(out.String(), "running") {
+		t.Errorf("expected the status line to be redrawn as running, got %q", out.String())
+	}
+
+	s.Stop()
+	if logs.String() != "epoch 1\nepo" {
+		t.Errorf("expected Stop to write the unfinished line, got %q", logs.String())
+	}
+}
+
+func TestStatusLineProgressBars(t *testing.T) {
+	var out, logs bytes.Buffer
+	s := &statusLine{tty: true, out: &out, logs: &logs, done: make(chan struct{})}
+
+	s.Write([]byte("loading\n"))
+	s.Write([]byte(" 10%|█         |\r"))
+	s.Write([]byte(" 20%|██        |\r"))
+	if logs.String() != "loading\n 10%|█         |\r 20%|██        |\r" {
+		t.Errorf("expected progress bars to pass through unchanged, got %q", logs.String())
+	}
+	if !s.stopped {
+		t.Error("expected the status line to stop for a job drawing its own progress")
+	}
+}
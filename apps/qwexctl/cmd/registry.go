@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Quatton/qwex/apps/qwexctl/internal/pods"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var (
+	registryUsername      string
+	registryPasswordStdin bool
+)
+
+var registryCmd = &cobra.Command{
+	Use:   "registry",
+	Short: "Manage container registry credentials for private images",
+	Long: `Credentials are stored as image pull secrets in the namespace and attached
+to the dev workspace and every batch job.`,
+}
+
+var registryLoginCmd = &cobra.Command{
+	Use:   "login [server]",
+	Short: "Store or rotate credentials for a registry (e.g. ghcr.io)",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		svc := cmd.Context().Value("service").(*Service)
+		server := args[0]
+
+		if registryUsername == "" {
+			return fmt.Errorf("--username is required")
+		}
+
+		password, err := readRegistryPassword()
+		if err != nil {
+			return err
+		}
+		if password == "" {
+			return fmt.Errorf("password must not be empty")
+		}
+
+		podService := pods.NewService(svc.K8s.Clientset, svc.Namespace)
+		secret, err := podService.UpsertRegistrySecret(cmd.Context(), server, registryUsername, password)
+		if err != nil {
+			return err
+		}
+
+		say("✅ Credentials for %s saved to secret %s\n", server, secret.Name)
+		say("💡 Run 'qwexctl up' to roll them out to the dev workspace\n")
+		return nil
+	},
+}
+
+var registryLogoutCmd = &cobra.Command{
+	Use:   "logout [server]",
+	Short: "Remove stored credentials for a registry",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		svc := cmd.Context().Value("service").(*Service)
+
+		podService := pods.NewService(svc.K8s.Clientset, svc.Namespace)
+		if err := podService.DeleteRegistrySecret(cmd.Context(), args[0]); err != nil {
+			return err
+		}
+
+		say("Removed credentials for %s\n", args[0])
+		return nil
+	},
+}
+
+var registryListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List registries with stored credentials",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		svc := cmd.Context().Value("service").(*Service)
+
+		podService := pods.NewService(svc.K8s.Clientset, svc.Namespace)
+		secrets, err := podService.ListRegistrySecrets(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SERVER\tSECRET\tCREATED")
+		for _, secret := range secrets {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", secret.Annotations[pods.RegistryServerAnnotation], secret.Name, formatTime(secret.CreationTimestamp.Time))
+		}
+		return w.Flush()
+	},
+}
+
+func readRegistryPassword() (string, error) {
+	if registryPasswordStdin {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("failed to read password from stdin: %w", err)
+		}
+		return strings.TrimSpace(line), nil
+	}
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", fmt.Errorf("stdin is not a terminal; use --password-stdin")
+	}
+
+	fmt.Print("Password: ")
+	password, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	if err != nil {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+	return string(password), nil
+}
+
+func init() {
+	rootCmd.AddCommand(registryCmd)
+	registryCmd.AddCommand(registryLoginCmd)
+	registryCmd.AddCommand(registryLogoutCmd)
+	registryCmd.AddCommand(registryListCmd)
+
+	registryLoginCmd.Flags().StringVarP(&registryUsername, "username", "u", "", "Registry username")
+	registryLoginCmd.Flags().BoolVar(&registryPasswordStdin, "password-stdin", false, "Read the password or token from stdin")
+}
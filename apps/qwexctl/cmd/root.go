@@ -2,8 +2,13 @@ package cmd
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"log"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 
 	"github.com/Quatton/qwex/apps/qwexctl/internal/k8s"
 	"github.com/spf13/cobra"
@@ -26,6 +31,10 @@ var rootCmd = &cobra.Command{
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		cmd.Flags().SetInterspersed(false)
 
+		if err := validateTimeFormat(); err != nil {
+			return err
+		}
+
 		globalService, err := initServiceManual()
 		if err != nil {
 			return err
@@ -53,9 +62,49 @@ func initServiceManual() (*Service, error) {
 }
 
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		os.Exit(1)
+	if handled, err := runPlugin(os.Args[1:]); handled {
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(exitError)
+		}
+		return
+	}
+
+	// Ctrl-C and SIGTERM cancel the command's context so it can clean up, e.g. a
+	// run queued in a concurrency group is deleted; a second signal exits at once
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	executed, err := executeRoot(ctx)
+	stop()
+	reportTelemetry(executed, err)
+	if err != nil {
+		os.Exit(exitCode(err))
+	}
+}
+
+// executeRoot runs the command line, giving every argument mistake exitUsage
+func executeRoot(ctx context.Context) (*cobra.Command, error) {
+	wrapArgsValidators(rootCmd)
+
+	executed, err := rootCmd.ExecuteContextC(ctx)
+	if err != nil {
+		// cobra rejects an unknown top-level command before any validator runs
+		if strings.HasPrefix(err.Error(), "unknown command ") {
+			err = withExitCode(exitUsage, err)
+		}
+		return executed, err
+	}
+
+	// For an unknown subcommand of a group like 'runs', cobra only shows the help
+	if executed != nil && !executed.Runnable() && executed.Flags().NArg() > 0 {
+		err = withExitCode(exitUsage, fmt.Errorf("unknown command %q for %q", executed.Flags().Arg(0), executed.CommandPath()))
+		executed.PrintErrln(executed.ErrPrefix(), err.Error())
 	}
+	return executed, err
 }
 
 func init() {
@@ -65,7 +114,19 @@ func init() {
 
 	rootCmd.PersistentFlags().StringVarP(&namespace, "namespace", "n", "qwex-demo", "kubernetes namespace for dev environment")
 
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "only print results (run ids, final status) on stdout")
+
+	rootCmd.PersistentFlags().String("time-format", timeRelative, "how to print timestamps: relative, local or iso (RFC 3339 UTC, for scripts)")
+
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return withExitCode(exitUsage, err)
+	})
+
+	rootCmd.PersistentFlags().StringP("workspace", "w", "", "named dev workspace to use (default is the current one from config)")
+
 	viper.BindPFlag("namespace", rootCmd.PersistentFlags().Lookup("namespace"))
+	viper.BindPFlag(workspaceKey, rootCmd.PersistentFlags().Lookup("workspace"))
+	viper.BindPFlag(timeFormatKey, rootCmd.PersistentFlags().Lookup("time-format"))
 }
 
 func initConfig() {
@@ -85,4 +146,12 @@ func initConfig() {
 	if err := viper.ReadInConfig(); err == nil {
 		// log.Println("Using config file:", viper.ConfigFileUsed())
 	}
+
+	if cfgFile == "" {
+		mergeProjectConfig()
+	}
+
+	if quiet {
+		log.SetOutput(io.Discard)
+	}
 }
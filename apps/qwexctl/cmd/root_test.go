@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"testing"
+)
+
+func TestExecuteRootUsageErrors(t *testing.T) {
+	rootCmd.SetOut(io.Discard)
+	rootCmd.SetErr(io.Discard)
+	t.Cleanup(func() {
+		rootCmd.SetArgs(nil)
+		rootCmd.SetOut(nil)
+		rootCmd.SetErr(nil)
+	})
+
+	for _, args := range [][]string{
+		{"bogus"},
+		{"runs", "bogus"},
+		{"logs"},
+		{"diff", "run-a"},
+		{"runs", "env"},
+		{"runs", "download", "run-a", "run-b"},
+		{"pipeline"},
+		{"gc", "extra"},
+	} {
+		rootCmd.SetArgs(args)
+		_, err := executeRoot(context.Background())
+		if code := exitCode(err); err == nil || code != exitUsage {
+			t.Errorf("%v: got exit code %d (%v), want %d", args, code, err, exitUsage)
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Quatton/qwex/apps/qwexctl/internal/batch"
+	"github.com/Quatton/qwex/apps/qwexctl/internal/connect"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/batch/v1"
+)
+
+var (
+	runsSelector  string
+	runsCancelAll bool
+	runsForce     bool
+	runsDryRun    bool
+	runsOutput    string
+	runsEnvJSON   bool
+)
+
+var runsCmd = &cobra.Command{
+	Use:   "runs",
+	Short: "Manage batch runs",
+}
+
+var runsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List batch runs, with the progress running jobs report in $QWEX_STATUS_FILE",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		svc := cmd.Context().Value("service").(*Service)
+		ctx := cmd.Context()
+
+		localRepoPath := connect.GetLocalRepoPath(cfgFile)
+		connectService := connect.NewService(svc.K8s.Clientset, svc.K8s.Config, svc.Namespace, "", "", localRepoPath)
+		batchService := batch.NewService(connectService, "", "", nil, nil, "", "")
+
+		jobs, err := batchService.ListRuns(ctx, runsSelector)
+		if err != nil {
+			return err
+		}
+		if len(jobs) == 0 {
+			say("No runs found\n")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "RUN ID\tSTATUS\tCREATED\tPROGRESS")
+		for _, job := range jobs {
+			runID := job.Labels[batch.RunIDLabel]
+			status := batch.JobStatus(&job)
+			if batch.IsSmokeRun(job.Labels) {
+				status += " (smoke)"
+			}
+			progress := ""
+			if !batch.IsJobFinished(&job) {
+				// A pod we can't exec into just shows no progress
+				progress, _ = batchService.RunProgress(ctx, runID)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", runID, status, formatTime(job.CreationTimestamp.Time), progress)
+		}
+		return w.Flush()
+	},
+}
+
+var runsCancelCmd = &cobra.Command{
+	Use:   "cancel [run-id...]",
+	Short: "Cancel one or more active batch runs",
+	Long: `Cancel runs by run-id, or every active run matching a label selector:
+
+  qwexctl runs cancel -l sweep=bert --dry-run
+  qwexctl runs cancel -l sweep=bert --all
+
+Cancelling more than one run with a selector requires --all.
+Runs get SIGTERM and their termination grace period to clean up;
+--force kills them immediately instead.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		svc := cmd.Context().Value("service").(*Service)
+		ctx := cmd.Context()
+
+		if len(args) == 0 && runsSelector == "" {
+			return fmt.Errorf("specify run ids or a selector with -l")
+		}
+		if len(args) > 0 && runsSelector != "" {
+			return fmt.Errorf("specify either run ids or a selector, not both")
+		}
+
+		localRepoPath := connect.GetLocalRepoPath(cfgFile)
+		connectService := connect.NewService(svc.K8s.Clientset, svc.K8s.Config, svc.Namespace, "", "", localRepoPath)
+		batchService := batch.NewService(connectService, "", "", nil, nil, "", "")
+
+		var targets []v1.Job
+		if runsSelector != "" {
+			jobs, err := batchService.ListRuns(ctx, runsSelector)
+			if err != nil {
+				return err
+			}
+			for _, job := range jobs {
+				if job.DeletionTimestamp == nil && !batch.IsJobFinished(&job) {
+					targets = append(targets, job)
+				}
+			}
+		} else {
+			for _, runID := range args {
+				job, err := batchService.GetRunJob(ctx, runID)
+				if err != nil {
+					return err
+				}
+				// Deleting a finished run would only throw away its logs and status early
+				if job.DeletionTimestamp != nil {
+					say("⏭️  Skipping run %s, already being cancelled\n", runID)
+					continue
+				}
+				if batch.IsJobFinished(job) {
+					say("⏭️  Skipping run %s, already %s\n", runID, strings.ToLower(batch.JobStatus(job)))
+					continue
+				}
+				targets = append(targets, *job)
+			}
+		}
+
+		if len(targets) == 0 {
+			say("No active runs match\n")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "RUN ID\tJOB\tSTATUS")
+		for _, job := range targets {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", job.Labels[batch.RunIDLabel], job.Name, batch.JobStatus(&job))
+		}
+		w.Flush()
+
+		if runsDryRun {
+			say("\n%d run(s) would be cancelled (dry run)\n", len(targets))
+			return nil
+		}
+		if runsSelector != "" && len(targets) > 1 && !runsCancelAll {
+			return fmt.Errorf("%d runs match %q; pass --all to cancel them all", len(targets), runsSelector)
+		}
+
+		cancelled := 0
+		for _, job := range targets {
+			cancel := batchService.CancelJob
+			if runsForce {
+				cancel = batchService.ForceCancelJob
+			}
+			if err := cancel(ctx, &job); err != nil {
+				fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+				continue
+			}
+			cancelled++
+		}
+
+		say("🛑 Cancelled %d of %d run(s)\n", cancelled, len(targets))
+		if cancelled < len(targets) {
+			return fmt.Errorf("failed to cancel %d run(s)", len(targets)-cancelled)
+		}
+		return nil
+	},
+}
+
+var runsDownloadCmd = &cobra.Command{
+	Use:   "download [run-id]",
+	Short: "Download a run's spec, pod status and logs as a tar.gz",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		svc := cmd.Context().Value("service").(*Service)
+		ctx := cmd.Context()
+		runID := args[0]
+
+		localRepoPath := connect.GetLocalRepoPath(cfgFile)
+		connectService := connect.NewService(svc.K8s.Clientset, svc.K8s.Config, svc.Namespace, "", "", localRepoPath)
+		batchService := batch.NewService(connectService, "", "", nil, nil, "", "")
+
+		var err error
+		batchService.RedactPatterns, err = redactPatterns()
+		if err != nil {
+			return err
+		}
+
+		output := runsOutput
+		if output == "" {
+			output = runID + ".tar.gz"
+		}
+
+		if output == "-" {
+			return batchService.WriteRunBundle(ctx, runID, os.Stdout)
+		}
+
+		f, err := os.Create(output)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", output, err)
+		}
+		defer f.Close()
+
+		if err := batchService.WriteRunBundle(ctx, runID, f); err != nil {
+			os.Remove(output)
+			return err
+		}
+
+		say("📦 Saved run %s to %s\n", runID, output)
+		return nil
+	},
+}
+
+var runsEnvCmd = &cobra.Command{
+	Use:   "env [run-id]",
+	Short: "Show the image digest and node environment a run executed on",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		svc := cmd.Context().Value("service").(*Service)
+		ctx := cmd.Context()
+
+		localRepoPath := connect.GetLocalRepoPath(cfgFile)
+		connectService := connect.NewService(svc.K8s.Clientset, svc.K8s.Config, svc.Namespace, "", "", localRepoPath)
+		batchService := batch.NewService(connectService, "", "", nil, nil, "", "")
+
+		env, err := batchService.GetRunEnvironment(ctx, args[0])
+		if err != nil {
+			return err
+		}
+
+		if runsEnvJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(env)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		rows := [][2]string{
+			{"run-id", env.RunID},
+			{"sha", env.Sha},
+			{"image", env.Image},
+			{"image digest", env.ImageDigest},
+			{"node", env.Node},
+			{"os", env.OS},
+			{"kernel", env.Kernel},
+			{"arch", env.Arch},
+			{"runtime", env.Runtime},
+			{"kubelet", env.Kubelet},
+			{"cpu limit", env.CPU},
+			{"memory limit", env.Memory},
+		}
+		for _, label := range slices.Sorted(maps.Keys(env.GPU)) {
+			rows = append(rows, [2]string{label, env.GPU[label]})
+		}
+		for _, row := range rows {
+			if row[1] != "" {
+				fmt.Fprintf(w, "%s\t%s\n", row[0], row[1])
+			}
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(runsCmd)
+	runsCmd.AddCommand(runsListCmd)
+	runsCmd.AddCommand(runsCancelCmd)
+	runsCmd.AddCommand(runsDownloadCmd)
+	runsCmd.AddCommand(runsEnvCmd)
+
+	runsListCmd.Flags().StringVarP(&runsSelector, "selector", "l", "", "Label selector, e.g. sweep=bert")
+	runsCancelCmd.Flags().StringVarP(&runsSelector, "selector", "l", "", "Label selector, e.g. sweep=bert")
+	runsCancelCmd.Flags().BoolVar(&runsCancelAll, "all", false, "Cancel every matching run")
+	runsCancelCmd.Flags().BoolVar(&runsForce, "force", false, "Kill runs immediately, skipping their cleanup grace period")
+	runsCancelCmd.Flags().BoolVar(&runsDryRun, "dry-run", false, "Only list the runs that would be cancelled")
+	runsEnvCmd.Flags().BoolVar(&runsEnvJSON, "json", false, "Print as environment.json")
+	runsDownloadCmd.Flags().StringVarP(&runsOutput, "output", "o", "", "Output file, - for stdout (default: <run-id>.tar.gz)")
+}
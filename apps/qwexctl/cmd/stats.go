@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Quatton/qwex/apps/qwexctl/internal/batch"
+	"github.com/Quatton/qwex/apps/qwexctl/internal/connect"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	statsInterval time.Duration
+	statsWidth    int
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats [run-id]",
+	Short: "Show live CPU and memory usage of a running batch job",
+	Long: `Sample the batch container's usage from metrics-server while the run is live
+and draw it as sparklines against the container limits. Exits when the run finishes.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		svc := cmd.Context().Value("service").(*Service)
+		ctx := cmd.Context()
+		runID := args[0]
+
+		if statsInterval <= 0 {
+			return withExitCode(exitUsage, fmt.Errorf("--interval must be positive, got %s", statsInterval))
+		}
+		if statsWidth < 1 {
+			return withExitCode(exitUsage, fmt.Errorf("--width must be at least 1, got %d", statsWidth))
+		}
+
+		localRepoPath := connect.GetLocalRepoPath(cfgFile)
+		connectService := connect.NewService(svc.K8s.Clientset, svc.K8s.Config, svc.Namespace, "", "", localRepoPath)
+		batchService := batch.NewService(connectService, "", "", nil, nil, "", "")
+
+		podName, err := batchService.WaitForRunReady(ctx, runID, 2*time.Minute)
+		if err != nil {
+			return fmt.Errorf("error waiting for pod to be running: %w", err)
+		}
+
+		var cpu, memory []float64
+		drawn := false
+		failures := 0
+		ticker := time.NewTicker(statsInterval)
+		defer ticker.Stop()
+
+		for {
+			pod, err := svc.K8s.Clientset.CoreV1().Pods(svc.Namespace).Get(ctx, podName, metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to get pod %s: %w", podName, err)
+			}
+			if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+				fmt.Printf("Run %s finished (%s)\n", runID, pod.Status.Phase)
+				return nil
+			}
+
+			usage, err := batchService.SampleUsage(ctx, podName)
+			if err != nil {
+				// New pods have no metrics until metrics-server's next scrape
+				failures++
+				if failures >= 6 {
+					return err
+				}
+				if !drawn {
+					fmt.Printf("\033[2KWaiting for metrics...\r")
+				}
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-ticker.C:
+				}
+				continue
+			}
+			failures = 0
+			cpu = append(cpu, float64(usage.CPUMilli))
+			memory = append(memory, float64(usage.MemoryBytes))
+			if len(cpu) > statsWidth {
+				cpu, memory = cpu[1:], memory[1:]
+			}
+
+			cpuLimit, memoryLimit, gpus := batch.ContainerLimits(pod)
+			if drawn {
+				// Move back over the previous frame
+				fmt.Print("\033[3A")
+			}
+			fmt.Printf("\033[2KCPU  %s %.2f / %.2f cores\n", batch.Sparkline(cpu, float64(cpuLimit)), float64(usage.CPUMilli)/1000, float64(cpuLimit)/1000)
+			fmt.Printf("\033[2KMEM  %s %s / %s\n", batch.Sparkline(memory, float64(memoryLimit)), batch.FormatBytes(usage.MemoryBytes), batch.FormatBytes(memoryLimit))
+			if gpus > 0 {
+				fmt.Printf("\033[2KGPU  %d requested (utilization is not exposed by metrics-server)\n", gpus)
+			} else {
+				fmt.Printf("\033[2KGPU  none requested\n")
+			}
+			drawn = true
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+	statsCmd.Flags().DurationVar(&statsInterval, "interval", 5*time.Second, "Sampling interval")
+	statsCmd.Flags().IntVar(&statsWidth, "width", 40, "Number of samples to keep in each graph")
+}
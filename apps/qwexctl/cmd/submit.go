@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Quatton/qwex/apps/qwexctl/internal/batch"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var submitCmd = &cobra.Command{
+	Use:   "submit",
+	Short: "Interactively build and submit a batch job",
+	Long: `Walk through image, job name, command, resources, env and options for a
+batch job, then print the equivalent 'qwexctl batch' command line and submit it.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		reader := bufio.NewReader(os.Stdin)
+
+		defaultImage, err := batchImage("")
+		if err != nil {
+			return err
+		}
+
+		var chosenImage string
+		for {
+			chosenImage, err = prompt(reader, "Image", defaultImage)
+			if err != nil {
+				return err
+			}
+			if err := validateImages(chosenImage); err != nil {
+				fmt.Printf("  %v\n", err)
+				continue
+			}
+			break
+		}
+
+		name, err := prompt(reader, "Job name", "job")
+		if err != nil {
+			return err
+		}
+
+		var command string
+		for command == "" {
+			command, err = prompt(reader, "Command (run with /bin/sh -c)", "")
+			if err != nil {
+				return err
+			}
+		}
+
+		group, err := prompt(reader, "Concurrency group (empty for none)", "")
+		if err != nil {
+			return err
+		}
+		if group != "" {
+			if err := batch.ValidateConcurrencyGroup(group); err != nil {
+				return err
+			}
+		}
+
+		resources, err := promptResources(reader)
+		if err != nil {
+			return err
+		}
+
+		var envValues []string
+		for {
+			value, err := prompt(reader, "Env NAME=VALUE (empty to finish)", "")
+			if err != nil {
+				return err
+			}
+			if value == "" {
+				break
+			}
+			if _, err := batch.ParseEnvVar(value); err != nil {
+				fmt.Printf("  %v\n", err)
+				continue
+			}
+			envValues = append(envValues, value)
+		}
+
+		followAnswer, err := prompt(reader, "Follow logs? [y/N]", "n")
+		if err != nil {
+			return err
+		}
+
+		image, batchName, concurrencyGroup = chosenImage, name, group
+		batchCPU, batchMemory, batchGPU = resources.CPU, resources.Memory, resources.GPU
+		batchEnv = envValues
+		follow = strings.HasPrefix(strings.ToLower(followAnswer), "y")
+		batchArgs := []string{"/bin/sh", "-c", command}
+
+		fmt.Printf("\nEquivalent command:\n  %s\n\n", batchCommandLine(viper.GetString("namespace"), batchArgs))
+
+		confirm, err := prompt(reader, "Submit now? [Y/n]", "y")
+		if err != nil {
+			return err
+		}
+		if !strings.HasPrefix(strings.ToLower(confirm), "y") {
+			return nil
+		}
+
+		return batchCmd.RunE(cmd, batchArgs)
+	},
+}
+
+// promptResources asks for CPU, memory and GPUs until they validate; empty keeps the defaults
+func promptResources(reader *bufio.Reader) (batch.Resources, error) {
+	for {
+		var r batch.Resources
+		var err error
+		if r.CPU, err = prompt(reader, "CPU, e.g. 4 or 500m (empty for default)", ""); err != nil {
+			return r, err
+		}
+		if r.Memory, err = prompt(reader, "Memory, e.g. 16Gi (empty for default)", ""); err != nil {
+			return r, err
+		}
+		gpus, err := prompt(reader, "GPUs", "0")
+		if err != nil {
+			return r, err
+		}
+		if r.GPU, err = strconv.ParseInt(gpus, 10, 64); err != nil {
+			fmt.Printf("  invalid gpu %q: expected a number\n", gpus)
+			continue
+		}
+		if err := r.Validate(); err != nil {
+			fmt.Printf("  %v\n", err)
+			continue
+		}
+		return r, nil
+	}
+}
+
+func prompt(reader *bufio.Reader, label, defaultValue string) (string, error) {
+	if defaultValue != "" {
+		fmt.Printf("%s [%s]: ", label, defaultValue)
+	} else {
+		fmt.Printf("%s: ", label)
+	}
+
+	line, err := reader.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", fmt.Errorf("aborted: %w", err)
+	}
+
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return defaultValue, nil
+	}
+	return line, nil
+}
+
+func batchCommandLine(ns string, args []string) string {
+	parts := []string{"qwexctl", "batch", "-n", shellQuote(ns)}
+	if cfgFile != "" {
+		parts = append(parts, "--config", shellQuote(cfgFile))
+	}
+	parts = append(parts, "-i", shellQuote(image), "-j", shellQuote(batchName))
+	if batchCPU != "" {
+		parts = append(parts, "--cpu", shellQuote(batchCPU))
+	}
+	if batchMemory != "" {
+		parts = append(parts, "--memory", shellQuote(batchMemory))
+	}
+	if batchGPU != 0 {
+		parts = append(parts, "--gpu", strconv.FormatInt(batchGPU, 10))
+	}
+	for _, value := range batchEnv {
+		parts = append(parts, "-e", shellQuote(value))
+	}
+	if concurrencyGroup != "" {
+		parts = append(parts, "--concurrency-group", shellQuote(concurrencyGroup))
+	}
+	if follow {
+		parts = append(parts, "-f")
+	}
+	parts = append(parts, "--")
+	for _, arg := range args {
+		parts = append(parts, shellQuote(arg))
+	}
+	return strings.Join(parts, " ")
+}
+
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r == '-' || r == '_' || r == '.' || r == '/' || r == ':' || r == '=' ||
+			(r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9'))
+	}) == -1 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func init() {
+	rootCmd.AddCommand(submitCmd)
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestBatchCommandLine(t *testing.T) {
+	cfg, img, name, group, follows := cfgFile, image, batchName, concurrencyGroup, follow
+	cpu, memory, gpus, env := batchCPU, batchMemory, batchGPU, batchEnv
+	t.Cleanup(func() {
+		cfgFile, image, batchName, concurrencyGroup, follow = cfg, img, name, group, follows
+		batchCPU, batchMemory, batchGPU, batchEnv = cpu, memory, gpus, env
+	})
+
+	cfgFile, image, batchName = "/home/me/qwex.yaml", "python:3.12", "train"
+	concurrencyGroup, follow = "", true
+	batchCPU, batchMemory, batchGPU = "4", "16Gi", 1
+	batchEnv = []string{"LR=0.1", "WANDB_API_KEY={{ secret \"WANDB_API_KEY\" }}"}
+
+	expected := `qwexctl batch -n team-a --config /home/me/qwex.yaml -i python:3.12 -j train --cpu 4 --memory 16Gi --gpu 1 ` +
+		`-e LR=0.1 -e 'WANDB_API_KEY={{ secret "WANDB_API_KEY" }}' -f -- /bin/sh -c 'python train.py'`
+	if actual := batchCommandLine("team-a", []string{"/bin/sh", "-c", "python train.py"}); actual != expected {
+		t.Errorf("expected\n  %s\ngot\n  %s", expected, actual)
+	}
+}
+
+func TestPromptResources(t *testing.T) {
+	// An invalid memory value is asked for again
+	reader := bufio.NewReader(strings.NewReader("4\nlots\n0\n4\n16Gi\n1\n"))
+	r, err := promptResources(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.CPU != "4" || r.Memory != "16Gi" || r.GPU != 1 {
+		t.Errorf("unexpected resources %+v", r)
+	}
+}
@@ -14,7 +14,11 @@ var connectCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		service := cmd.Context().Value("service").(*Service)
 		namespace := cmd.Flag("namespace").Value.String()
-		podService := pods.NewService(service.K8s.Clientset, namespace)
+		podService, err := newPodService(service)
+		if err != nil {
+			fmt.Printf("Error configuring development workspace: %v\n", err)
+			return
+		}
 
 		dep, err := podService.GetOrCreateDevelopmentDeployment(cmd.Context(), pods.Active)
 		if err != nil {
@@ -32,6 +36,10 @@ var connectCmd = &cobra.Command{
 		localRepoPath := connect.GetLocalRepoPath(cmd.Flag("config").Value.String())
 
 		connectService := connect.NewService(service.K8s.Clientset, service.K8s.Config, namespace, pod.Name, pods.SyncContainerName, localRepoPath)
+		if err := applySyncHooks(connectService); err != nil {
+			fmt.Printf("Error configuring sync hooks: %v\n", err)
+			return
+		}
 
 		err = connectService.SyncOnce(cmd.Context())
 		if err != nil && err.Error() != "up_to_date" {
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Quatton/qwex/apps/qwexctl/internal/telemetry"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var telemetryCmd = &cobra.Command{
+	Use:   "telemetry",
+	Short: "Manage opt-in anonymous usage telemetry",
+	Long: `Telemetry is disabled by default. When enabled, qwexctl reports the command
+name, a coarse error class, version and OS/arch to the configured endpoint.
+Code, arguments, and environment variables are never sent.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return nil
+	},
+}
+
+var telemetryStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show telemetry status",
+	Run: func(cmd *cobra.Command, args []string) {
+		state := "disabled"
+		if viper.GetBool(telemetry.EnabledKey) {
+			state = "enabled"
+		}
+		fmt.Printf("Telemetry: %s\n", state)
+
+		endpoint := viper.GetString(telemetry.EndpointKey)
+		if endpoint == "" {
+			endpoint = "(none, nothing will be sent)"
+		}
+		fmt.Printf("Endpoint:  %s\n", endpoint)
+	},
+}
+
+var telemetryEnableCmd = &cobra.Command{
+	Use:   "enable",
+	Short: "Opt in to anonymous usage telemetry",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		values := map[string]any{telemetry.EnabledKey: true}
+		if viper.GetString(telemetry.InstallKey) == "" {
+			values[telemetry.InstallKey] = uuid.New().String()
+		}
+
+		path, err := writeUserConfig(values)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Telemetry enabled (saved to %s)\n", path)
+		return nil
+	},
+}
+
+var telemetryDisableCmd = &cobra.Command{
+	Use:   "disable",
+	Short: "Opt out of anonymous usage telemetry",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := writeUserConfig(map[string]any{telemetry.EnabledKey: false})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Telemetry disabled (saved to %s)\n", path)
+		return nil
+	},
+}
+
+// writeUserConfig persists values to the config file without leaking flag values into it
+func writeUserConfig(values map[string]any) (string, error) {
+	path := viper.ConfigFileUsed()
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(home, ".qwexctl.yaml")
+	}
+
+	v := viper.New()
+	v.SetConfigFile(path)
+	if _, err := os.Stat(path); err == nil {
+		if err := v.ReadInConfig(); err != nil {
+			return "", fmt.Errorf("failed to read config %s: %w", path, err)
+		}
+	}
+
+	for key, value := range values {
+		v.Set(key, value)
+		viper.Set(key, value)
+	}
+
+	if err := v.WriteConfigAs(path); err != nil {
+		return "", fmt.Errorf("failed to write config %s: %w", path, err)
+	}
+	return path, nil
+}
+
+func reportTelemetry(executed *cobra.Command, err error) {
+	if executed == nil || !viper.GetBool(telemetry.EnabledKey) {
+		return
+	}
+
+	client := telemetry.NewClient(
+		viper.GetString(telemetry.EndpointKey),
+		viper.GetString(telemetry.InstallKey),
+		Version,
+	)
+	_ = client.Send(context.Background(), client.NewEvent(executed.CommandPath(), err))
+}
+
+func init() {
+	rootCmd.AddCommand(telemetryCmd)
+	telemetryCmd.AddCommand(telemetryStatusCmd)
+	telemetryCmd.AddCommand(telemetryEnableCmd)
+	telemetryCmd.AddCommand(telemetryDisableCmd)
+}
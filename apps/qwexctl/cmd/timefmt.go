@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const timeFormatKey = "output.time"
+
+const (
+	timeRelative = "relative" // "3m ago", the default for people
+	timeLocal    = "local"    // 2006-01-02 15:04:05 in the local timezone
+	timeISO      = "iso"      // RFC 3339 in UTC, for scripts
+)
+
+func validateTimeFormat() error {
+	switch viper.GetString(timeFormatKey) {
+	case timeRelative, timeLocal, timeISO:
+		return nil
+	default:
+		return withExitCode(exitUsage, fmt.Errorf("invalid time format %q: expected %s, %s or %s", viper.GetString(timeFormatKey), timeRelative, timeLocal, timeISO))
+	}
+}
+
+// formatTime renders a timestamp in the configured --time-format
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	switch viper.GetString(timeFormatKey) {
+	case timeISO:
+		return t.UTC().Format(time.RFC3339)
+	case timeLocal:
+		return t.Local().Format("2006-01-02 15:04:05")
+	}
+
+	d := time.Since(t)
+	switch {
+	case d < 0:
+		return "in " + formatDuration(-d)
+	case d < time.Second:
+		return "just now"
+	default:
+		return formatDuration(d) + " ago"
+	}
+}
+
+// formatDuration keeps the two most significant units, e.g. 2h15m or 3d4h
+func formatDuration(d time.Duration) string {
+	if viper.GetString(timeFormatKey) == timeISO {
+		return d.Round(time.Second).String()
+	}
+
+	d = d.Round(time.Second)
+	days := d / (24 * time.Hour)
+	hours := d % (24 * time.Hour) / time.Hour
+	minutes := d % time.Hour / time.Minute
+	seconds := d % time.Minute / time.Second
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd%dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh%dm", hours, minutes)
+	case minutes > 0:
+		return fmt.Sprintf("%dm%ds", minutes, seconds)
+	default:
+		return fmt.Sprintf("%ds", seconds)
+	}
+}
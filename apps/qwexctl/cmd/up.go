@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/Quatton/qwex/apps/qwexctl/internal/pods"
+	"github.com/spf13/cobra"
+)
+
+var (
+	upGPU     int
+	upGPUType string
+)
+
+var upCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Create or update the remote development workspace",
+	Long: `Create or update the development deployment and wait until it is ready.
+Use --gpu to request GPUs for the dev container; the setting sticks for
+later exec/sync until changed again (--gpu 0 removes it).`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		svc := cmd.Context().Value("service").(*Service)
+		ctx := cmd.Context()
+
+		podService, err := newPodService(svc)
+		if err != nil {
+			return err
+		}
+
+		if cmd.Flags().Changed("gpu") || cmd.Flags().Changed("gpu-type") {
+			if upGPU < 0 {
+				return fmt.Errorf("--gpu must not be negative")
+			}
+			if upGPUType != "" && upGPU == 0 {
+				return fmt.Errorf("--gpu-type requires --gpu")
+			}
+			podService.GPU = &pods.GPUOptions{Count: upGPU, Type: upGPUType}
+		}
+
+		dep, err := podService.GetOrCreateDevelopmentDeployment(ctx, pods.Active)
+		if err != nil {
+			return err
+		}
+
+		pod, err := podService.GetPodFromDeployment(ctx, dep)
+		if err != nil {
+			return err
+		}
+
+		say("✅ Workspace ready: %s\n", pod.Name)
+		if gpus := dep.Annotations[pods.GPUCountAnnotation]; gpus != "" {
+			say("🎮 GPUs: %s %s\n", gpus, dep.Annotations[pods.GPUTypeAnnotation])
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(upCmd)
+	upCmd.Flags().IntVar(&upGPU, "gpu", 0, "Number of GPUs for the dev container (uses a CUDA image)")
+	upCmd.Flags().StringVar(&upGPUType, "gpu-type", "", "GPU product to schedule on, matched against the nvidia.com/gpu.product node label")
+}
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Version is overridden at build time:
+// go build -ldflags "-X github.com/Quatton/qwex/apps/qwexctl/cmd.Version=v0.1.0"
+var Version = "dev"
+
+// latestReleaseURL is the GitHub API for the newest qwexctl release
+var latestReleaseURL = "https://api.github.com/repos/Quatton/qwex/releases/latest"
+
+const releaseCheckTimeout = 5 * time.Second
+
+var checkVersion bool
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print the qwexctl version",
+	Long: `Print the qwexctl version. With --check, also look up the latest release
+on GitHub and print where to download it if this build is out of date.`,
+	// Doesn't need a cluster, so skip the root service setup
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Printf("qwexctl %s (commit: %s, %s %s/%s)\n", Version, buildCommit(), runtime.Version(), runtime.GOOS, runtime.GOARCH)
+		if !checkVersion {
+			return nil
+		}
+
+		latest, err := latestRelease(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("failed to check for a newer release: %w", err)
+		}
+		switch {
+		case Version == "dev":
+			say("💡 This is a development build; the latest release is %s: %s\n", latest.TagName, latest.HTMLURL)
+		case isNewerVersion(latest.TagName, Version):
+			say("⬆️  qwexctl %s is available, download it from %s\n", latest.TagName, latest.HTMLURL)
+		default:
+			say("✅ qwexctl is up to date\n")
+		}
+		return nil
+	},
+}
+
+type release struct {
+	TagName string `json:"tag_name"`
+	HTMLURL string `json:"html_url"`
+}
+
+func latestRelease(ctx context.Context) (*release, error) {
+	ctx, cancel := context.WithTimeout(ctx, releaseCheckTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, latestReleaseURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GitHub returned %s", resp.Status)
+	}
+	var latest release
+	if err := json.NewDecoder(resp.Body).Decode(&latest); err != nil {
+		return nil, fmt.Errorf("invalid release response: %w", err)
+	}
+	if latest.TagName == "" {
+		return nil, fmt.Errorf("invalid release response: no tag name")
+	}
+	return &latest, nil
+}
+
+// isNewerVersion compares vMAJOR.MINOR.PATCH tags; anything unparsable is never newer
+func isNewerVersion(latest, current string) bool {
+	l, ok := parseVersion(latest)
+	if !ok {
+		return false
+	}
+	c, ok := parseVersion(current)
+	if !ok {
+		return false
+	}
+	for i := range l {
+		if l[i] != c[i] {
+			return l[i] > c[i]
+		}
+	}
+	return false
+}
+
+func parseVersion(v string) ([3]int, bool) {
+	var parts [3]int
+	v, _, _ = strings.Cut(strings.TrimPrefix(v, "v"), "-")
+	fields := strings.Split(v, ".")
+	if len(fields) != 3 {
+		return parts, false
+	}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	return parts, true
+}
+
+func buildCommit() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" {
+			if len(setting.Value) >= 7 {
+				return setting.Value[:7]
+			}
+			return setting.Value
+		}
+	}
+	return "unknown"
+}
+
+func init() {
+	rootCmd.AddCommand(versionCmd)
+	versionCmd.Flags().BoolVar(&checkVersion, "check", false, "Check GitHub for a newer release")
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIsNewerVersion(t *testing.T) {
+	cases := []struct {
+		latest, current string
+		expected        bool
+	}{
+		{"v0.2.0", "v0.1.9", true},
+		{"v1.0.0", "v0.10.0", true},
+		{"v0.1.10", "v0.1.9", true},
+		{"v0.1.0", "v0.1.0", false},
+		{"v0.1.0", "v0.2.0", false},
+		{"v0.2.0-rc.1", "v0.1.0", true},
+		{"v0.2.0", "dev", false},
+		{"nightly", "v0.1.0", false},
+	}
+	for _, tc := range cases {
+		if actual := isNewerVersion(tc.latest, tc.current); actual != tc.expected {
+			t.Errorf("isNewerVersion(%q, %q) = %v, expected %v", tc.latest, tc.current, actual, tc.expected)
+		}
+	}
+}
+
+func TestLatestRelease(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"tag_name": "v0.3.0", "html_url": "https://github.com/Quatton/qwex/releases/tag/v0.3.0"}`))
+	}))
+	defer server.Close()
+
+	url := latestReleaseURL
+	latestReleaseURL = server.URL
+	t.Cleanup(func() { latestReleaseURL = url })
+
+	latest, err := latestRelease(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest.TagName != "v0.3.0" || latest.HTMLURL != "https://github.com/Quatton/qwex/releases/tag/v0.3.0" {
+		t.Errorf("unexpected release %+v", latest)
+	}
+}
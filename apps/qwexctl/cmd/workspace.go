@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/Quatton/qwex/apps/qwexctl/internal/pods"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var workspaceCmd = &cobra.Command{
+	Use:   "workspace",
+	Short: "Subcommand for named dev workspaces",
+	Long: `Each workspace is a separate dev deployment with its own PVCs, so you can
+work on several branches in parallel. Create one with 'qwexctl up -w <name>'.`,
+}
+
+var workspaceListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List dev workspaces in the namespace",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		svc := cmd.Context().Value("service").(*Service)
+
+		podService, err := newPodService(svc)
+		if err != nil {
+			return err
+		}
+
+		workspaces, err := podService.ListWorkspaces(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		current := viper.GetString(workspaceKey)
+		if current == "" {
+			current = pods.DefaultWorkspace
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "\tNAME\tDEPLOYMENT\tREADY\tGPUS\tCREATED")
+		for _, ws := range workspaces {
+			marker := ""
+			if ws.Name == current {
+				marker = "*"
+			}
+			gpus := ws.GPUs
+			if gpus == "" {
+				gpus = "-"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%t\t%s\t%s\n", marker, ws.Name, ws.Deployment, ws.Ready, gpus, formatTime(ws.CreatedAt))
+		}
+		return w.Flush()
+	},
+}
+
+var workspaceUseCmd = &cobra.Command{
+	Use:   "use [name]",
+	Short: "Switch the default workspace for later commands",
+	Args:  cobra.ExactArgs(1),
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if err := pods.ValidateWorkspaceName(name); err != nil {
+			return err
+		}
+
+		path, err := writeUserConfig(map[string]any{workspaceKey: name})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Switched to workspace %s (saved to %s)\n", name, path)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(workspaceCmd)
+	workspaceCmd.AddCommand(workspaceListCmd)
+	workspaceCmd.AddCommand(workspaceUseCmd)
+}
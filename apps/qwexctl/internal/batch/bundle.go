@@ -0,0 +1,94 @@
+package batch
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WriteRunBundle writes a tar.gz of the run's job, pod and logs for sharing or archival
+func (s *Service) WriteRunBundle(ctx context.Context, runID string, w io.Writer) error {
+	job, err := s.GetRunJob(ctx, runID)
+	if err != nil {
+		return err
+	}
+
+	podList, err := s.connector.Client.CoreV1().Pods(s.connector.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", RunIDLabel, runID),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list pods for run %s: %w", runID, err)
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	environment, err := s.GetRunEnvironment(ctx, runID)
+	if err != nil {
+		return err
+	}
+
+	files := map[string]any{"run.json": job, "environment.json": environment}
+	order := []string{"run.json", "environment.json"}
+	if len(podList.Items) > 0 {
+		files["pod.json"] = &podList.Items[0]
+		order = append(order, "pod.json")
+	}
+	for _, name := range order {
+		data, err := json.MarshalIndent(files[name], "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", name, err)
+		}
+		if err := addTarFile(tw, runID+"/"+name, data); err != nil {
+			return err
+		}
+	}
+
+	if len(podList.Items) > 0 {
+		var logs bytes.Buffer
+		if err := s.streamLogsFromPod(ctx, podList.Items[0].Name, &logs, false); err != nil {
+			return err
+		}
+		if err := addTarFile(tw, runID+"/logs.txt", logs.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	script, err := s.GetRunScript(ctx, runID)
+	if err != nil {
+		return err
+	}
+	if script != nil {
+		if err := addTarFile(tw, runID+"/script/"+script.Name, script.Content); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to finish bundle: %w", err)
+	}
+	return gz.Close()
+}
+
+func addTarFile(tw *tar.Writer, name string, data []byte) error {
+	err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add %s to bundle: %w", name, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("failed to add %s to bundle: %w", name, err)
+	}
+	return nil
+}
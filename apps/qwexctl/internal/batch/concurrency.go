@@ -0,0 +1,207 @@
+package batch
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	ConcurrencyGroupLabel = "qwex.dev/concurrency-group"
+
+	// GroupHeartbeatAnnotation is refreshed by a queued run's submitter while it waits
+	GroupHeartbeatAnnotation = "qwex.dev/heartbeat"
+)
+
+type ConcurrencyPolicy string
+
+const (
+	// ConcurrencyQueue waits for active runs in the group to finish before submitting
+	ConcurrencyQueue ConcurrencyPolicy = "queue"
+	// ConcurrencyCancel cancels active runs in the group, superseding them
+	ConcurrencyCancel ConcurrencyPolicy = "cancel"
+)
+
+func ParseConcurrencyPolicy(s string) (ConcurrencyPolicy, error) {
+	switch p := ConcurrencyPolicy(s); p {
+	case ConcurrencyQueue, ConcurrencyCancel:
+		return p, nil
+	}
+	return "", fmt.Errorf("invalid concurrency policy %q (expected %q or %q)", s, ConcurrencyQueue, ConcurrencyCancel)
+}
+
+func ValidateConcurrencyGroup(group string) error {
+	if errs := validation.IsValidLabelValue(group); len(errs) > 0 {
+		return fmt.Errorf("invalid concurrency group %q: %s", group, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func IsJobFinished(job *v1.Job) bool {
+	for _, c := range job.Status.Conditions {
+		if (c.Type == v1.JobComplete || c.Type == v1.JobFailed) && c.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// listActiveGroupJobs lists the unfinished runs in the group. Abandoned runs,
+// held by a submitter that has since gone, are cancelled and left out so they
+// don't block the group forever; self is never treated as abandoned.
+func (s *Service) listActiveGroupJobs(ctx context.Context, self *v1.Job) ([]v1.Job, error) {
+	jobList, err := s.connector.Client.BatchV1().Jobs(s.connector.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", ConcurrencyGroupLabel, s.ConcurrencyGroup),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs in concurrency group %s: %w", s.ConcurrencyGroup, err)
+	}
+
+	var active []v1.Job
+	for _, job := range jobList.Items {
+		if job.DeletionTimestamp != nil || IsJobFinished(&job) {
+			continue
+		}
+		if job.Name != self.Name && IsAbandonedGroupJob(&job, time.Now()) {
+			log.Printf("Removing abandoned run %s in concurrency group %s", job.Labels[RunIDLabel], s.ConcurrencyGroup)
+			if err := s.CancelJob(ctx, &job); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		active = append(active, job)
+	}
+	return active, nil
+}
+
+// How often a queued run checks whether it's next
+var concurrencyPollInterval = 5 * time.Second
+
+// A suspended run whose submitter hasn't checked in for this long is abandoned:
+// well past the poll interval, with room for clock skew between submitters
+var groupHeartbeatTimeout = 2 * time.Minute
+
+func lastHeartbeat(job *v1.Job) time.Time {
+	if t, err := time.Parse(time.RFC3339, job.Annotations[GroupHeartbeatAnnotation]); err == nil {
+		return t
+	}
+	return job.CreationTimestamp.Time
+}
+
+// IsAbandonedGroupJob reports a run still held back by its concurrency group
+// whose submitter stopped waiting for it, e.g. because it was killed
+func IsAbandonedGroupJob(job *v1.Job, now time.Time) bool {
+	return job.Labels[ConcurrencyGroupLabel] != "" && isJobSuspended(job) && !IsJobFinished(job) &&
+		now.Sub(lastHeartbeat(job)) > groupHeartbeatTimeout
+}
+
+// runsBefore orders runs in a group by creation, with the name breaking ties,
+// so every submitter agrees on which run goes first
+func runsBefore(a, b *v1.Job) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+	return a.Name < b.Name
+}
+
+func isJobSuspended(job *v1.Job) bool {
+	return job.Spec.Suspend != nil && *job.Spec.Suspend
+}
+
+// heartbeat tells other submitters that job is still queued by a live submitter
+func (s *Service) heartbeat(ctx context.Context, job *v1.Job) error {
+	patch := []byte(fmt.Sprintf(`{"metadata":{"annotations":{%q:%q}}}`, GroupHeartbeatAnnotation, time.Now().UTC().Format(time.RFC3339)))
+	_, err := s.connector.Client.BatchV1().Jobs(s.connector.Namespace).Patch(ctx, job.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to refresh job %s while it waits: %w", job.Name, err)
+	}
+	return nil
+}
+
+func (s *Service) resumeJob(ctx context.Context, job *v1.Job) error {
+	patch := []byte(`{"spec":{"suspend":false}}`)
+	_, err := s.connector.Client.BatchV1().Jobs(s.connector.Namespace).Patch(ctx, job.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to start job %s: %w", job.Name, err)
+	}
+	return nil
+}
+
+// acquireConcurrencyGroup decides when job, created suspended, may start. Checking
+// after creation closes the race where two submitters both see an empty group:
+// both jobs exist by the time either decides, and both order them the same way.
+//   - queue: start once no earlier run in the group is active and no other run has started
+//   - cancel: cancel earlier active runs and start; if a newer run exists, this one is
+//     already superseded and is cancelled instead
+//
+// If acquiring fails or is interrupted, job is deleted so no suspended run is left behind.
+// A submitter killed outright can't do that, so while queued it refreshes a heartbeat
+// annotation; other submitters and 'qwexctl gc' remove runs whose heartbeat went stale.
+func (s *Service) acquireConcurrencyGroup(ctx context.Context, job *v1.Job) (err error) {
+	if s.ConcurrencyGroup == "" {
+		return nil
+	}
+
+	defer func() {
+		if err != nil {
+			_ = s.CancelJob(context.WithoutCancel(ctx), job)
+		}
+	}()
+
+	if s.ConcurrencyPolicy == ConcurrencyCancel {
+		active, err := s.listActiveGroupJobs(ctx, job)
+		if err != nil {
+			return err
+		}
+		for _, other := range active {
+			if other.Name != job.Name && runsBefore(job, &other) {
+				return fmt.Errorf("superseded by newer run %s", other.Labels[RunIDLabel])
+			}
+		}
+		for _, other := range active {
+			if other.Name == job.Name {
+				continue
+			}
+			log.Printf("Cancelling superseded run %s in concurrency group %s", other.Labels[RunIDLabel], s.ConcurrencyGroup)
+			if err := s.CancelJob(ctx, &other); err != nil {
+				return err
+			}
+		}
+		return s.resumeJob(ctx, job)
+	}
+
+	waiting := false
+	err = wait.PollUntilContextCancel(ctx, concurrencyPollInterval, true, func(ctx context.Context) (bool, error) {
+		if err := s.heartbeat(ctx, job); err != nil {
+			return false, err
+		}
+		active, err := s.listActiveGroupJobs(ctx, job)
+		if err != nil {
+			return false, err
+		}
+		blocking := 0
+		for _, other := range active {
+			if other.Name != job.Name && (runsBefore(&other, job) || !isJobSuspended(&other)) {
+				blocking++
+			}
+		}
+		if blocking > 0 && !waiting {
+			log.Printf("Waiting for %d active run(s) in concurrency group %s...", blocking, s.ConcurrencyGroup)
+			waiting = true
+		}
+		return blocking == 0, nil
+	})
+	if err != nil {
+		return err
+	}
+	return s.resumeJob(ctx, job)
+}
@@ -0,0 +1,182 @@
+package batch
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Quatton/qwex/apps/qwexctl/internal/connect"
+	v1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func groupJob(name string, created time.Time, suspended bool) *v1.Job {
+	return &v1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "ns",
+			CreationTimestamp: metav1.NewTime(created),
+			Labels:            map[string]string{ConcurrencyGroupLabel: "deploy", RunIDLabel: name},
+		},
+		Spec: v1.JobSpec{Suspend: &suspended},
+	}
+}
+
+func groupService(client *fake.Clientset, policy ConcurrencyPolicy) *Service {
+	s := NewService(&connect.Service{Client: client, Namespace: "ns"}, "", "", nil, nil, "", "")
+	s.ConcurrencyGroup = "deploy"
+	s.ConcurrencyPolicy = policy
+	return s
+}
+
+func getJob(t *testing.T, client *fake.Clientset, name string) *v1.Job {
+	t.Helper()
+	job, err := client.BatchV1().Jobs("ns").Get(context.Background(), name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return job
+}
+
+func fastPolling(t *testing.T) {
+	interval := concurrencyPollInterval
+	concurrencyPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { concurrencyPollInterval = interval })
+}
+
+func TestAcquireConcurrencyGroupQueue(t *testing.T) {
+	fastPolling(t)
+	now := time.Now()
+
+	// Two runs submitted at the same time: only the first in group order may start
+	first, second := groupJob("job-a", now, true), groupJob("job-b", now, true)
+	client := fake.NewSimpleClientset(first, second)
+	s := groupService(client, ConcurrencyQueue)
+
+	if err := s.acquireConcurrencyGroup(context.Background(), first); err != nil {
+		t.Fatal(err)
+	}
+	if isJobSuspended(getJob(t, client, "job-a")) {
+		t.Error("expected the first run to start")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := s.acquireConcurrencyGroup(ctx, second); err == nil {
+		t.Fatal("expected the second run to wait while the first is active")
+	}
+	if getJob(t, client, "job-b") != nil {
+		t.Error("expected an interrupted queued run to be deleted")
+	}
+}
+
+func TestAcquireConcurrencyGroupQueueAfterFinish(t *testing.T) {
+	fastPolling(t)
+	now := time.Now()
+
+	done := groupJob("job-a", now.Add(-time.Minute), false)
+	done.Status.Conditions = []v1.JobCondition{{Type: v1.JobComplete, Status: corev1.ConditionTrue}}
+	queued := groupJob("job-b", now, true)
+	client := fake.NewSimpleClientset(done, queued)
+
+	if err := groupService(client, ConcurrencyQueue).acquireConcurrencyGroup(context.Background(), queued); err != nil {
+		t.Fatal(err)
+	}
+	if isJobSuspended(getJob(t, client, "job-b")) {
+		t.Error("expected the run to start once the group has no active runs")
+	}
+}
+
+func TestAcquireConcurrencyGroupAbandoned(t *testing.T) {
+	fastPolling(t)
+	now := time.Now()
+
+	// An earlier queued run whose submitter was killed before it could clean up
+	abandoned := groupJob("job-a", now.Add(-time.Hour), true)
+	ours := groupJob("job-b", now, true)
+	client := fake.NewSimpleClientset(abandoned, ours)
+
+	if err := groupService(client, ConcurrencyQueue).acquireConcurrencyGroup(context.Background(), ours); err != nil {
+		t.Fatal(err)
+	}
+	if getJob(t, client, "job-a") != nil {
+		t.Error("expected the abandoned run to be removed")
+	}
+	if isJobSuspended(getJob(t, client, "job-b")) {
+		t.Error("expected our run to start past the abandoned one")
+	}
+
+	// The same run is still waited for while its submitter keeps checking in
+	waiting := groupJob("job-a", now.Add(-time.Hour), true)
+	waiting.Annotations = map[string]string{GroupHeartbeatAnnotation: now.UTC().Format(time.RFC3339)}
+	ours = groupJob("job-b", now, true)
+	client = fake.NewSimpleClientset(waiting, ours)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := groupService(client, ConcurrencyQueue).acquireConcurrencyGroup(ctx, ours); err == nil {
+		t.Fatal("expected our run to wait behind a live queued run")
+	}
+	if getJob(t, client, "job-a") == nil {
+		t.Error("expected a queued run with a fresh heartbeat to be kept")
+	}
+}
+
+func TestAcquireConcurrencyGroupHeartbeat(t *testing.T) {
+	fastPolling(t)
+	now := time.Now()
+
+	running := groupJob("job-a", now.Add(-time.Hour), false)
+	queued := groupJob("job-b", now.Add(-time.Hour), true)
+	client := fake.NewSimpleClientset(running, queued)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	s := groupService(client, ConcurrencyQueue)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = s.acquireConcurrencyGroup(ctx, queued)
+	}()
+	defer func() { <-done }()
+
+	// A run queued long ago stays fresh while its submitter is waiting
+	err := wait.PollUntilContextCancel(ctx, 5*time.Millisecond, true, func(context.Context) (bool, error) {
+		job := getJob(t, client, "job-b")
+		return job != nil && !IsAbandonedGroupJob(job, time.Now()), nil
+	})
+	if err != nil {
+		t.Error("expected the waiting submitter to refresh its heartbeat")
+	}
+}
+
+func TestAcquireConcurrencyGroupCancel(t *testing.T) {
+	now := time.Now()
+	older, ours, newer := groupJob("job-a", now.Add(-time.Minute), false), groupJob("job-b", now, true), groupJob("job-c", now.Add(time.Second), true)
+
+	client := fake.NewSimpleClientset(older, ours)
+	if err := groupService(client, ConcurrencyCancel).acquireConcurrencyGroup(context.Background(), ours); err != nil {
+		t.Fatal(err)
+	}
+	if getJob(t, client, "job-a") != nil {
+		t.Error("expected the older run to be cancelled")
+	}
+	if isJobSuspended(getJob(t, client, "job-b")) {
+		t.Error("expected our run to start")
+	}
+
+	client = fake.NewSimpleClientset(older, ours, newer)
+	if err := groupService(client, ConcurrencyCancel).acquireConcurrencyGroup(context.Background(), ours); err == nil {
+		t.Error("expected a run superseded by a newer one to fail")
+	}
+	if getJob(t, client, "job-b") != nil {
+		t.Error("expected the superseded run to be cancelled")
+	}
+}
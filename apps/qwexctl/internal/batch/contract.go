@@ -0,0 +1,129 @@
+package batch
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// ContractViolation is reported for runs that exited 0 without producing what they promised
+	ContractViolation = "contract_violation"
+
+	// MetricsFileEnv points jobs at a flat JSON object of final metrics, e.g. {"accuracy": 0.93}
+	MetricsFileEnv  = "QWEX_METRICS_FILE"
+	MetricsFilePath = "/tmp/qwex-metrics.json"
+)
+
+var metricCheckPattern = regexp.MustCompile(`^\s*([A-Za-z0-9_./-]+)\s*(>=|<=|==|!=|>|<)\s*(\S+)\s*$`)
+
+// Contract lists what a successful run must leave behind; it is checked in the
+// batch container after the command exits 0
+type Contract struct {
+	// Files must exist and be non-empty, relative to the workdir
+	Files []string `json:"files,omitempty"`
+	// Metrics are checks like "accuracy >= 0.9" against $QWEX_METRICS_FILE
+	Metrics []string `json:"metrics,omitempty"`
+}
+
+type MetricCheck struct {
+	Name      string
+	Op        string
+	Threshold float64
+}
+
+func (m MetricCheck) String() string {
+	return fmt.Sprintf("%s %s %s", m.Name, m.Op, strconv.FormatFloat(m.Threshold, 'g', -1, 64))
+}
+
+func ParseMetricCheck(value string) (MetricCheck, error) {
+	match := metricCheckPattern.FindStringSubmatch(value)
+	if match == nil {
+		return MetricCheck{}, fmt.Errorf("invalid metric check %q: expected NAME OP NUMBER, e.g. accuracy >= 0.9", value)
+	}
+	threshold, err := strconv.ParseFloat(match[3], 64)
+	if err != nil {
+		return MetricCheck{}, fmt.Errorf("invalid metric check %q: %s is not a number", value, match[3])
+	}
+	return MetricCheck{Name: match[1], Op: match[2], Threshold: threshold}, nil
+}
+
+func (c *Contract) Empty() bool {
+	return c == nil || (len(c.Files) == 0 && len(c.Metrics) == 0)
+}
+
+func (c *Contract) Validate() error {
+	for _, file := range c.Files {
+		if file == "" || path.IsAbs(file) || path.Clean(file) != file || strings.HasPrefix(file, "..") {
+			return fmt.Errorf("invalid expected file %q: must be a clean path inside the workdir", file)
+		}
+	}
+	for _, metric := range c.Metrics {
+		if _, err := ParseMetricCheck(metric); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// contractScript runs the command given as "$@" and, if it succeeds, checks the
+// contract. Violations go to the termination log so FailureReason can report them.
+// sh doesn't pass signals on to its children, so TERM is forwarded to let the
+// command's own cleanup run when the run is cancelled.
+func contractScript(c *Contract) string {
+	lines := []string{
+		`"$@" &`,
+		`child=$!`,
+		`trap 'kill -TERM "$child" 2>/dev/null' TERM INT`,
+		`wait "$child"`,
+		`code=$?`,
+		`while kill -0 "$child" 2>/dev/null; do wait "$child"; code=$?; done`,
+		`[ "$code" -eq 0 ] || exit "$code"`,
+		`violations=""`,
+		`violate() { violations="$violations; $1"; }`,
+	}
+	for _, file := range c.Files {
+		lines = append(lines, fmt.Sprintf("[ -s %s ] || violate %s", quote(file), quote("missing output "+file)))
+	}
+	for _, metric := range c.Metrics {
+		m, _ := ParseMetricCheck(metric)
+		name := strings.NewReplacer(".", `\.`, "/", `\/`).Replace(m.Name)
+		sed := fmt.Sprintf(`s/.*"%s"[[:space:]]*:[[:space:]]*\([-+0-9.eE]*\).*/\1/p`, name)
+		lines = append(lines,
+			fmt.Sprintf(`v=$(sed -n %s "$%s" 2>/dev/null | head -n 1)`, quote(sed), MetricsFileEnv),
+			"if [ -z \"$v\" ]; then",
+			fmt.Sprintf("  violate %s", quote("metric "+m.Name+" not reported")),
+			fmt.Sprintf(`elif ! awk -v v="$v" 'BEGIN { exit !(v %s %s) }'; then`, m.Op, strconv.FormatFloat(m.Threshold, 'g', -1, 64)),
+			fmt.Sprintf(`  violate %s"$v)"`, quote(m.String()+" (got ")),
+			"fi",
+		)
+	}
+	lines = append(lines,
+		`[ -z "$violations" ] && exit 0`,
+		fmt.Sprintf(`msg="%s: ${violations#; }"`, ContractViolation),
+		`echo "$msg" >&2`,
+		`echo "$msg" > /dev/termination-log`,
+		`exit 1`,
+	)
+	return strings.Join(lines, "\n")
+}
+
+// applyContract wraps the batch command in the contract checks
+func (s *Service) applyContract(podSpec *corev1.PodSpec) {
+	if s.Contract.Empty() {
+		return
+	}
+
+	for i := range podSpec.Containers {
+		c := &podSpec.Containers[i]
+		if c.Name != BatchContainerName {
+			continue
+		}
+		c.Args = append(append([]string{}, c.Command...), c.Args...)
+		c.Command = []string{"/bin/sh", "-c", contractScript(s.Contract), "qwex-contract"}
+	}
+}
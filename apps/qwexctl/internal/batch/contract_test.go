@@ -0,0 +1,108 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/Quatton/qwex/apps/qwexctl/internal/connect"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestParseMetricCheck(t *testing.T) {
+	m, err := ParseMetricCheck("val/accuracy>=0.9")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m != (MetricCheck{Name: "val/accuracy", Op: ">=", Threshold: 0.9}) {
+		t.Errorf("unexpected check %+v", m)
+	}
+	if m.String() != "val/accuracy >= 0.9" {
+		t.Errorf("unexpected string %q", m.String())
+	}
+
+	for _, invalid := range []string{"accuracy", "accuracy => 0.9", "accuracy >= high", "acc uracy > 1"} {
+		if _, err := ParseMetricCheck(invalid); err == nil {
+			t.Errorf("expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestContractValidate(t *testing.T) {
+	if err := (&Contract{Files: []string{"out/model.pt"}, Metrics: []string{"loss < 0.5"}}).Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	for _, file := range []string{"/etc/passwd", "../model.pt", "out//model.pt", ""} {
+		if err := (&Contract{Files: []string{file}}).Validate(); err == nil {
+			t.Errorf("expected file %q to be rejected", file)
+		}
+	}
+}
+
+func TestApplyContract(t *testing.T) {
+	podSpec := &corev1.PodSpec{Containers: []corev1.Container{{
+		Name:    BatchContainerName,
+		Command: []string{"python"},
+		Args:    []string{"train.py"},
+	}}}
+	(&Service{Contract: &Contract{Files: []string{"model.pt"}}}).applyContract(podSpec)
+
+	c := podSpec.Containers[0]
+	if len(c.Command) != 4 || c.Command[0] != "/bin/sh" {
+		t.Errorf("expected the command to be wrapped in sh, got %v", c.Command)
+	}
+	if !slices.Equal(c.Args, []string{"python", "train.py"}) {
+		t.Errorf("expected the original command as args, got %v", c.Args)
+	}
+
+	untouched := &corev1.PodSpec{Containers: []corev1.Container{{Name: BatchContainerName, Command: []string{"python"}}}}
+	(&Service{}).applyContract(untouched)
+	if !slices.Equal(untouched.Containers[0].Command, []string{"python"}) {
+		t.Errorf("expected no contract to leave the command alone, got %v", untouched.Containers[0].Command)
+	}
+}
+
+func TestFailureReasonContractViolation(t *testing.T) {
+	pod := &corev1.Pod{Status: corev1.PodStatus{
+		Phase: corev1.PodFailed,
+		ContainerStatuses: []corev1.ContainerStatus{{
+			Name: BatchContainerName,
+			State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+				ExitCode: 1,
+				Reason:   "Error",
+				Message:  "contract_violation: missing output model.pt\n",
+			}},
+		}},
+	}}
+	if reason := FailureReason(pod); reason != ContractViolation {
+		t.Errorf("expected %s, got %q", ContractViolation, reason)
+	}
+}
+
+func TestCheckRunFailureContractViolation(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "run-pod", Namespace: "ns"},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodFailed,
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name: BatchContainerName,
+				State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+					ExitCode: 1,
+					Message:  "contract_violation: missing output model.pt\n",
+				}},
+			}},
+		},
+	}
+	s := NewService(&connect.Service{Client: fake.NewSimpleClientset(pod), Namespace: "ns"}, "", "", nil, nil, "", "")
+
+	var failed *RunFailedError
+	if err := s.checkRunFailure(context.Background(), "run-pod"); !errors.As(err, &failed) {
+		t.Fatalf("expected a *RunFailedError, got %v", err)
+	}
+	if failed.Reason != ContractViolation || failed.Message != "contract_violation: missing output model.pt" {
+		t.Errorf("unexpected failure %+v", failed)
+	}
+}
@@ -0,0 +1,100 @@
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	v1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const SpecHashLabel = "qwex.dev/spec-hash"
+
+// specHash identifies runs that would do exactly the same work
+func (s *Service) specHash(sha string) (string, error) {
+	scratch := ""
+	if s.Scratch != nil {
+		scratch = s.Scratch.String()
+	}
+	script := ""
+	if s.Script != nil {
+		script = s.Script.hash()
+	}
+	bytes, err := json.Marshal(struct {
+		Image     string
+		Command   []string
+		Args      []string
+		WorkDir   string
+		Sha       string
+		Inputs    []Input    `json:",omitempty"`
+		Env       []EnvVar   `json:",omitempty"`
+		Scratch   string     `json:",omitempty"`
+		Script    string     `json:",omitempty"`
+		Resources *Resources `json:",omitempty"`
+		Contract  *Contract  `json:",omitempty"`
+	}{
+		Image:     s.Image,
+		Command:   s.Command,
+		Args:      s.Args,
+		WorkDir:   s.WorkDir,
+		Sha:       sha,
+		Inputs:    s.Inputs,
+		Env:       s.Env,
+		Scratch:   scratch,
+		Script:    script,
+		Resources: s.Resources,
+		Contract:  s.Contract,
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", xxhash.Sum64(bytes)), nil
+}
+
+func IsJobSucceeded(job *v1.Job) bool {
+	for _, c := range job.Status.Conditions {
+		if c.Type == v1.JobComplete && c.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// findDuplicateRun returns a run with the same spec hash that succeeded within the dedup window
+func (s *Service) findDuplicateRun(ctx context.Context, specHash string) (*v1.Job, error) {
+	if s.DedupWindow <= 0 {
+		return nil, nil
+	}
+
+	jobList, err := s.connector.Client.BatchV1().Jobs(s.connector.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", SpecHashLabel, specHash),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs with spec hash %s: %w", specHash, err)
+	}
+
+	cutoff := time.Now().Add(-s.DedupWindow)
+	var latest *v1.Job
+	for i := range jobList.Items {
+		job := &jobList.Items[i]
+		if !IsJobSucceeded(job) || job.Status.CompletionTime == nil {
+			continue
+		}
+		if job.Status.CompletionTime.Time.Before(cutoff) {
+			continue
+		}
+		if latest == nil || job.Status.CompletionTime.After(latest.Status.CompletionTime.Time) {
+			latest = job
+		}
+	}
+
+	if latest != nil {
+		log.Printf("Found identical run %s that succeeded at %s, skipping submission", latest.Labels[RunIDLabel], latest.Status.CompletionTime.Format(time.RFC3339))
+	}
+	return latest, nil
+}
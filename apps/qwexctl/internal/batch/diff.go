@@ -0,0 +1,127 @@
+package batch
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	v1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type FieldDiff struct {
+	Field   string
+	A       string
+	B       string
+	Changed bool
+}
+
+// RunNotFoundError means no job carries the run id: it was cancelled, or removed after its TTL
+type RunNotFoundError struct {
+	RunID string
+}
+
+func (e *RunNotFoundError) Error() string {
+	return fmt.Sprintf("run %s not found (finished jobs are removed after their TTL)", e.RunID)
+}
+
+func (s *Service) GetRunJob(ctx context.Context, runID string) (*v1.Job, error) {
+	jobList, err := s.connector.Client.BatchV1().Jobs(s.connector.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", RunIDLabel, runID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up run %s: %w", runID, err)
+	}
+	if len(jobList.Items) == 0 {
+		return nil, &RunNotFoundError{RunID: runID}
+	}
+	return &jobList.Items[0], nil
+}
+
+func batchContainer(job *v1.Job) *corev1.Container {
+	for i, c := range job.Spec.Template.Spec.Containers {
+		if c.Name == BatchContainerName {
+			return &job.Spec.Template.Spec.Containers[i]
+		}
+	}
+	return &corev1.Container{}
+}
+
+func JobStatus(job *v1.Job) string {
+	switch {
+	case IsJobSucceeded(job):
+		return "Succeeded"
+	case IsJobFinished(job):
+		return "Failed"
+	case job.Status.Active > 0:
+		return "Running"
+	}
+	return "Pending"
+}
+
+func envMap(c *corev1.Container) map[string]string {
+	env := map[string]string{}
+	for _, e := range c.Env {
+		if e.ValueFrom != nil {
+			env[e.Name] = fmt.Sprintf("ref:%v", e.ValueFrom)
+			continue
+		}
+		env[e.Name] = e.Value
+	}
+	return env
+}
+
+// DiffRuns compares two run Jobs field by field. Env values are never rendered.
+func DiffRuns(a, b *v1.Job) []FieldDiff {
+	ca, cb := batchContainer(a), batchContainer(b)
+
+	var diffs []FieldDiff
+	add := func(field, av, bv string) {
+		diffs = append(diffs, FieldDiff{Field: field, A: av, B: bv, Changed: av != bv})
+	}
+
+	add("run-id", a.Labels[RunIDLabel], b.Labels[RunIDLabel])
+	add("status", JobStatus(a), JobStatus(b))
+	add("commit", shortSha(a.Labels["qwex.dev/sha"]), shortSha(b.Labels["qwex.dev/sha"]))
+	add("image", ca.Image, cb.Image)
+	add("command", strings.Join(ca.Command, " "), strings.Join(cb.Command, " "))
+	add("args", strings.Join(ca.Args, " "), strings.Join(cb.Args, " "))
+	add("workdir", ca.WorkingDir, cb.WorkingDir)
+
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		ra, rb := ca.Resources.Limits[name], cb.Resources.Limits[name]
+		add(fmt.Sprintf("limits.%s", name), ra.String(), rb.String())
+	}
+
+	envA, envB := envMap(ca), envMap(cb)
+	keys := slices.Sorted(maps.Keys(envA))
+	for k := range envB {
+		if _, ok := envA[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+
+	for _, k := range keys {
+		av, aok := envA[k]
+		bv, bok := envB[k]
+		diffs = append(diffs, FieldDiff{
+			Field:   "env." + k,
+			A:       redactedPresence(aok),
+			B:       redactedPresence(bok),
+			Changed: aok != bok || av != bv,
+		})
+	}
+
+	return diffs
+}
+
+func redactedPresence(ok bool) string {
+	if ok {
+		return "<redacted>"
+	}
+	return "<unset>"
+}
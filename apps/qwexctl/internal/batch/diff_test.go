@@ -0,0 +1,51 @@
+package batch
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func makeRunJob(runID, image string, env ...corev1.EnvVar) *v1.Job {
+	return &v1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{RunIDLabel: runID, "qwex.dev/sha": "abcdef123456"},
+		},
+		Spec: v1.JobSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: BatchContainerName, Image: image, Command: []string{"python", "main.py"}, Env: env},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestDiffRuns(t *testing.T) {
+	a := makeRunJob("run-a", "python:3.12", corev1.EnvVar{Name: "TOKEN", Value: "secret-a"})
+	b := makeRunJob("run-b", "python:3.13", corev1.EnvVar{Name: "TOKEN", Value: "secret-b"})
+
+	changed := map[string]FieldDiff{}
+	for _, d := range DiffRuns(a, b) {
+		if d.Changed {
+			changed[d.Field] = d
+		}
+		if d.A == "secret-a" || d.B == "secret-b" {
+			t.Fatalf("Env values must be redacted, got %+v", d)
+		}
+	}
+
+	for _, field := range []string{"run-id", "image", "env.TOKEN"} {
+		if _, ok := changed[field]; !ok {
+			t.Errorf("Expected %s to be reported as changed", field)
+		}
+	}
+
+	if _, ok := changed["command"]; ok {
+		t.Errorf("Expected command to be unchanged")
+	}
+}
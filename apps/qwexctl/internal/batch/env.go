@@ -0,0 +1,123 @@
+package batch
+
+import (
+	"bytes"
+	"fmt"
+	"os/user"
+	"regexp"
+	"strings"
+	"text/template"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// EnvVar is a job environment variable whose value is a text/template rendered at submit time
+type EnvVar struct {
+	Name  string
+	Value string
+}
+
+// EnvData is what env templates can reference
+type EnvData struct {
+	RunID     string
+	Job       string
+	Sha       string
+	Image     string
+	Namespace string
+	Workspace string
+	User      EnvUser
+}
+
+type EnvUser struct {
+	Login string
+}
+
+const secretPlaceholder = "\x00qwex-secret\x00"
+
+// envFuncs is the full function set available to templates besides the text/template builtins.
+// secret must make up the whole value; it becomes a secretKeyRef so the value is never put in the
+// job spec. Log redaction does read the value back (see secretPatterns), when RBAC allows it.
+func envFuncs(ref **corev1.SecretKeySelector) template.FuncMap {
+	return template.FuncMap{
+		"secret": func(name string, key ...string) (string, error) {
+			if len(key) > 1 {
+				return "", fmt.Errorf("secret takes a secret name and an optional key")
+			}
+			k := name
+			if len(key) == 1 {
+				k = key[0]
+			}
+			*ref = &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: strings.ToLower(strings.ReplaceAll(name, "_", "-"))},
+				Key:                  k,
+			}
+			return secretPlaceholder, nil
+		},
+	}
+}
+
+// ParseEnvVar parses NAME=TEMPLATE and checks that the template compiles
+func ParseEnvVar(value string) (EnvVar, error) {
+	name, tmpl, ok := strings.Cut(value, "=")
+	if !ok || !envNamePattern.MatchString(name) {
+		return EnvVar{}, fmt.Errorf("invalid env %q: expected NAME=VALUE", value)
+	}
+
+	var ref *corev1.SecretKeySelector
+	if _, err := template.New(name).Funcs(envFuncs(&ref)).Parse(tmpl); err != nil {
+		return EnvVar{}, fmt.Errorf("invalid template for %s: %w", name, err)
+	}
+	return EnvVar{Name: name, Value: tmpl}, nil
+}
+
+func (e EnvVar) Render(data EnvData) (corev1.EnvVar, error) {
+	var ref *corev1.SecretKeySelector
+	t, err := template.New(e.Name).Funcs(envFuncs(&ref)).Option("missingkey=error").Parse(e.Value)
+	if err != nil {
+		return corev1.EnvVar{}, fmt.Errorf("invalid template for %s: %w", e.Name, err)
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return corev1.EnvVar{}, fmt.Errorf("failed to render %s: %w", e.Name, err)
+	}
+
+	if ref != nil {
+		if buf.String() != secretPlaceholder {
+			return corev1.EnvVar{}, fmt.Errorf("%s: secret must be the entire value", e.Name)
+		}
+		return corev1.EnvVar{Name: e.Name, ValueFrom: &corev1.EnvVarSource{SecretKeyRef: ref}}, nil
+	}
+	return corev1.EnvVar{Name: e.Name, Value: buf.String()}, nil
+}
+
+func currentLogin() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return ""
+}
+
+func (s *Service) renderEnv(runID, sha string) ([]corev1.EnvVar, error) {
+	data := EnvData{
+		RunID:     runID,
+		Job:       s.Name,
+		Sha:       sha,
+		Image:     s.Image,
+		Namespace: s.connector.Namespace,
+		Workspace: s.Workspace,
+		User:      EnvUser{Login: currentLogin()},
+	}
+
+	var env []corev1.EnvVar
+	for _, e := range s.Env {
+		rendered, err := e.Render(data)
+		if err != nil {
+			return nil, err
+		}
+		env = append(env, rendered)
+	}
+	return env, nil
+}
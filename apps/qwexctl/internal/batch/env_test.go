@@ -0,0 +1,53 @@
+package batch
+
+import "testing"
+
+func TestEnvVarRender(t *testing.T) {
+	data := EnvData{RunID: "train-20250101-000000-abcd1234", Job: "train", User: EnvUser{Login: "alice"}}
+
+	e, err := ParseEnvVar("OUT_DIR=/results/{{ .User.Login }}/{{ .RunID }}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rendered, err := e.Render(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rendered.Value != "/results/alice/train-20250101-000000-abcd1234" {
+		t.Errorf("unexpected value %q", rendered.Value)
+	}
+
+	e, err = ParseEnvVar(`WANDB_API_KEY={{ secret "WANDB_API_KEY" }}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rendered, err = e.Render(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rendered.Value != "" || rendered.ValueFrom == nil || rendered.ValueFrom.SecretKeyRef.Name != "wandb-api-key" || rendered.ValueFrom.SecretKeyRef.Key != "WANDB_API_KEY" {
+		t.Errorf("unexpected secret ref %+v", rendered)
+	}
+
+	e, err = ParseEnvVar(`TOKEN=Bearer {{ secret "api" "token" }}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.Render(data); err == nil {
+		t.Error("expected a partial secret value to be rejected")
+	}
+
+	e, err = ParseEnvVar("X={{ .Nope }}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.Render(data); err == nil {
+		t.Error("expected an unknown field to be rejected")
+	}
+
+	for _, value := range []string{"NOVALUE", "1X=a", "X={{ .RunID"} {
+		if _, err := ParseEnvVar(value); err == nil {
+			t.Errorf("expected %q to be rejected", value)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package batch
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Quatton/qwex/apps/qwexctl/internal/pods"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Labels set by NVIDIA GPU feature discovery on GPU nodes
+var gpuNodeLabels = []string{
+	pods.GPUProductLabel,
+	"nvidia.com/gpu.memory",
+	"nvidia.com/cuda.driver.major",
+	"nvidia.com/cuda.driver.minor",
+	"nvidia.com/cuda.runtime.major",
+	"nvidia.com/cuda.runtime.minor",
+}
+
+// RunEnvironment is what the cluster recorded about where and on what a run executed
+type RunEnvironment struct {
+	RunID       string            `json:"runId"`
+	Sha         string            `json:"sha"`
+	Image       string            `json:"image"`
+	ImageDigest string            `json:"imageDigest,omitempty"`
+	Node        string            `json:"node,omitempty"`
+	OS          string            `json:"os,omitempty"`
+	Kernel      string            `json:"kernel,omitempty"`
+	Arch        string            `json:"arch,omitempty"`
+	Runtime     string            `json:"containerRuntime,omitempty"`
+	Kubelet     string            `json:"kubelet,omitempty"`
+	CPU         string            `json:"cpu,omitempty"`
+	Memory      string            `json:"memory,omitempty"`
+	GPU         map[string]string `json:"gpu,omitempty"`
+}
+
+// GetRunEnvironment collects the image digest and node details of a run. It needs the pod,
+// so it only works until the job's TTL expires.
+func (s *Service) GetRunEnvironment(ctx context.Context, runID string) (*RunEnvironment, error) {
+	job, err := s.GetRunJob(ctx, runID)
+	if err != nil {
+		return nil, err
+	}
+
+	env := &RunEnvironment{
+		RunID: runID,
+		Sha:   job.Labels["qwex.dev/sha"],
+		Image: batchContainer(job).Image,
+	}
+
+	podList, err := s.connector.Client.CoreV1().Pods(s.connector.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", RunIDLabel, runID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods for run %s: %w", runID, err)
+	}
+	if len(podList.Items) == 0 {
+		return env, nil
+	}
+	pod := podList.Items[0]
+
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.Name == BatchContainerName {
+			env.ImageDigest = cs.ImageID
+		}
+	}
+	for _, c := range pod.Spec.Containers {
+		if c.Name == BatchContainerName {
+			env.CPU = c.Resources.Limits.Cpu().String()
+			env.Memory = c.Resources.Limits.Memory().String()
+		}
+	}
+
+	env.Node = pod.Spec.NodeName
+	if env.Node == "" {
+		return env, nil
+	}
+
+	node, err := s.connector.Client.CoreV1().Nodes().Get(ctx, env.Node, metav1.GetOptions{})
+	if err != nil {
+		// Reading nodes is often not allowed for namespace-scoped users
+		return env, nil
+	}
+	info := node.Status.NodeInfo
+	env.OS = info.OSImage
+	env.Kernel = info.KernelVersion
+	env.Arch = info.Architecture
+	env.Runtime = info.ContainerRuntimeVersion
+	env.Kubelet = info.KubeletVersion
+
+	for _, label := range gpuNodeLabels {
+		if value, ok := node.Labels[label]; ok {
+			if env.GPU == nil {
+				env.GPU = map[string]string{}
+			}
+			env.GPU[label] = value
+		}
+	}
+	return env, nil
+}
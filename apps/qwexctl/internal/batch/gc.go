@@ -0,0 +1,115 @@
+package batch
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/batch/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Leaves in-flight submissions alone: a script ConfigMap is created just before its job
+const orphanGracePeriod = 10 * time.Minute
+
+type Orphan struct {
+	Kind   string
+	Name   string
+	Reason string
+}
+
+// FindOrphans lists qwex-created resources that outlived the run they belong to
+func (s *Service) FindOrphans(ctx context.Context) ([]Orphan, error) {
+	client := s.connector.Client
+	ns := s.connector.Namespace
+	batchSelector := metav1.ListOptions{LabelSelector: TypeLabel + "=batch"}
+	cutoff := time.Now().Add(-orphanGracePeriod)
+
+	var orphans []Orphan
+
+	jobList, err := client.BatchV1().Jobs(ns).List(ctx, batchSelector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	jobUIDs := map[string]bool{}
+	runIDs := map[string]bool{}
+	for _, job := range jobList.Items {
+		jobUIDs[string(job.UID)] = true
+		runIDs[job.Labels[RunIDLabel]] = true
+
+		if IsAbandonedGroupJob(&job, time.Now()) {
+			orphans = append(orphans, Orphan{Kind: "Job", Name: job.Name, Reason: "queued in its concurrency group, but its submitter is gone"})
+			continue
+		}
+		if !IsJobFinished(&job) {
+			continue
+		}
+		// Finished jobs normally disappear via their TTL; these were left behind
+		if job.Spec.TTLSecondsAfterFinished == nil {
+			orphans = append(orphans, Orphan{Kind: "Job", Name: job.Name, Reason: "finished with no TTL"})
+		} else if expiry := jobFinishedAt(&job).Add(time.Duration(*job.Spec.TTLSecondsAfterFinished)*time.Second + orphanGracePeriod); time.Now().After(expiry) {
+			orphans = append(orphans, Orphan{Kind: "Job", Name: job.Name, Reason: "past its TTL (is the TTL controller running?)"})
+		}
+	}
+
+	podList, err := client.CoreV1().Pods(ns).List(ctx, batchSelector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	for _, pod := range podList.Items {
+		owned := false
+		for _, ref := range pod.OwnerReferences {
+			if ref.Kind == "Job" && jobUIDs[string(ref.UID)] {
+				owned = true
+			}
+		}
+		if !owned && pod.CreationTimestamp.Time.Before(cutoff) {
+			orphans = append(orphans, Orphan{Kind: "Pod", Name: pod.Name, Reason: "its job is gone"})
+		}
+	}
+
+	configMapList, err := client.CoreV1().ConfigMaps(ns).List(ctx, metav1.ListOptions{LabelSelector: RunIDLabel})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list config maps: %w", err)
+	}
+	for _, cm := range configMapList.Items {
+		if len(cm.OwnerReferences) == 0 && !runIDs[cm.Labels[RunIDLabel]] && cm.CreationTimestamp.Time.Before(cutoff) {
+			orphans = append(orphans, Orphan{Kind: "ConfigMap", Name: cm.Name, Reason: "script of a run that was never created"})
+		}
+	}
+
+	return orphans, nil
+}
+
+func jobFinishedAt(job *v1.Job) time.Time {
+	for _, c := range job.Status.Conditions {
+		if c.Type == v1.JobComplete || c.Type == v1.JobFailed {
+			return c.LastTransitionTime.Time
+		}
+	}
+	return job.CreationTimestamp.Time
+}
+
+func (s *Service) DeleteOrphan(ctx context.Context, orphan Orphan) error {
+	client := s.connector.Client
+	ns := s.connector.Namespace
+	prop := metav1.DeletePropagationBackground
+	opts := metav1.DeleteOptions{PropagationPolicy: &prop}
+
+	var err error
+	switch orphan.Kind {
+	case "Job":
+		err = client.BatchV1().Jobs(ns).Delete(ctx, orphan.Name, opts)
+	case "Pod":
+		err = client.CoreV1().Pods(ns).Delete(ctx, orphan.Name, opts)
+	case "ConfigMap":
+		err = client.CoreV1().ConfigMaps(ns).Delete(ctx, orphan.Name, opts)
+	default:
+		return fmt.Errorf("unknown orphan kind %s", orphan.Kind)
+	}
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete %s %s: %w", orphan.Kind, orphan.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package batch
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Quatton/qwex/apps/qwexctl/internal/connect"
+	v1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestFindOrphans(t *testing.T) {
+	old := metav1.NewTime(time.Now().Add(-time.Hour))
+	ttl := int32(300)
+	suspended := true
+	batchLabels := func(runID string) map[string]string {
+		return map[string]string{TypeLabel: "batch", RunIDLabel: runID}
+	}
+	finished := v1.JobStatus{Conditions: []v1.JobCondition{{Type: v1.JobComplete, Status: corev1.ConditionTrue, LastTransitionTime: old}}}
+
+	client := fake.NewSimpleClientset(
+		&v1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "live", Namespace: "ns", UID: "live-uid", Labels: batchLabels("live"), CreationTimestamp: old},
+			Spec:       v1.JobSpec{TTLSecondsAfterFinished: &ttl},
+		},
+		&v1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "stale", Namespace: "ns", Labels: batchLabels("stale"), CreationTimestamp: old},
+			Spec:       v1.JobSpec{TTLSecondsAfterFinished: &ttl},
+			Status:     finished,
+		},
+		&v1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "queued", Namespace: "ns", Labels: map[string]string{TypeLabel: "batch", RunIDLabel: "queued", ConcurrencyGroupLabel: "deploy"}, CreationTimestamp: old},
+			Spec:       v1.JobSpec{Suspend: &suspended},
+		},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name: "live-pod", Namespace: "ns", Labels: batchLabels("live"), CreationTimestamp: old,
+			OwnerReferences: []metav1.OwnerReference{{Kind: "Job", Name: "live", UID: "live-uid"}},
+		}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "lost-pod", Namespace: "ns", Labels: batchLabels("gone"), CreationTimestamp: old}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "qwex-script-gone", Namespace: "ns", Labels: map[string]string{RunIDLabel: "gone"}, CreationTimestamp: old}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "qwex-script-new", Namespace: "ns", Labels: map[string]string{RunIDLabel: "new"}, CreationTimestamp: metav1.Now()}},
+	)
+
+	s := NewService(&connect.Service{Client: client, Namespace: "ns"}, "", "", nil, nil, "", "")
+	orphans, err := s.FindOrphans(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := map[string]bool{}
+	for _, o := range orphans {
+		found[o.Kind+"/"+o.Name] = true
+	}
+	for _, expected := range []string{"Job/stale", "Job/queued", "Pod/lost-pod", "ConfigMap/qwex-script-gone"} {
+		if !found[expected] {
+			t.Errorf("expected %s to be reported, got %v", expected, orphans)
+		}
+	}
+	if len(orphans) != 4 {
+		t.Errorf("expected 4 orphans, got %v", orphans)
+	}
+}
@@ -0,0 +1,130 @@
+package batch
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/Quatton/qwex/apps/qwexctl/internal/pods"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const InputsContainerName = "init-inputs"
+
+// Downloads with a known checksum are kept here and reused by later runs
+const inputsCacheDir = pods.CacheMountPath + "/qwex-inputs"
+
+var sha256Pattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// Input is a file fetched into the job workdir before the command starts
+type Input struct {
+	URL    string `mapstructure:"url"`
+	Dest   string `mapstructure:"dest"`
+	SHA256 string `mapstructure:"sha256"`
+}
+
+// ParseInput parses DEST=URL[@sha256:HEX]
+func ParseInput(value string) (Input, error) {
+	dest, rawURL, ok := strings.Cut(value, "=")
+	if !ok {
+		return Input{}, fmt.Errorf("invalid input %q: expected DEST=URL[@sha256:HEX]", value)
+	}
+
+	input := Input{URL: rawURL, Dest: dest}
+	if i := strings.LastIndex(rawURL, "@sha256:"); i >= 0 {
+		input.URL, input.SHA256 = rawURL[:i], rawURL[i+len("@sha256:"):]
+	}
+
+	return input, input.Validate()
+}
+
+func (in Input) Validate() error {
+	u, err := url.Parse(in.URL)
+	if err != nil {
+		return fmt.Errorf("invalid input url %q: %w", in.URL, err)
+	}
+	switch u.Scheme {
+	case "http", "https":
+	case "s3":
+		if u.Host == "" {
+			return fmt.Errorf("invalid input url %q: missing bucket", in.URL)
+		}
+	default:
+		return fmt.Errorf("invalid input url %q: only http, https and s3 are supported", in.URL)
+	}
+
+	if in.Dest == "" || path.IsAbs(in.Dest) || path.Clean(in.Dest) != in.Dest || strings.HasPrefix(in.Dest, "..") {
+		return fmt.Errorf("invalid input dest %q: must be a clean path relative to the job workdir", in.Dest)
+	}
+
+	if in.SHA256 != "" && !sha256Pattern.MatchString(in.SHA256) {
+		return fmt.Errorf("invalid sha256 for %s: expected 64 lowercase hex characters", in.Dest)
+	}
+	return nil
+}
+
+// downloadURL maps s3://bucket/key to the bucket's public HTTPS endpoint
+func (in Input) downloadURL() string {
+	u, err := url.Parse(in.URL)
+	if err != nil || u.Scheme != "s3" {
+		return in.URL
+	}
+	return fmt.Sprintf("https://%s.s3.amazonaws.com/%s", u.Host, strings.TrimPrefix(u.Path, "/"))
+}
+
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func inputsScript(inputs []Input) string {
+	lines := []string{"set -e", fmt.Sprintf("mkdir -p %s", inputsCacheDir)}
+	for _, in := range inputs {
+		dest := quote(path.Join(BatchWorkDir, in.Dest))
+		tmp := quote(path.Join(BatchWorkDir, in.Dest) + ".part")
+
+		lines = append(lines, fmt.Sprintf(`mkdir -p "$(dirname %s)"`, dest))
+		if in.SHA256 == "" {
+			lines = append(lines,
+				fmt.Sprintf("echo 'Downloading %s'", in.Dest),
+				fmt.Sprintf("wget -q -O %s %s", dest, quote(in.downloadURL())),
+			)
+			continue
+		}
+
+		cached := path.Join(inputsCacheDir, in.SHA256)
+		lines = append(lines,
+			fmt.Sprintf("if [ -f %s ]; then", cached),
+			fmt.Sprintf("  echo 'Using cached %s'; cp %s %s", in.Dest, cached, dest),
+			"else",
+			fmt.Sprintf("  echo 'Downloading %s'", in.Dest),
+			fmt.Sprintf("  wget -q -O %s %s", tmp, quote(in.downloadURL())),
+			fmt.Sprintf("  echo %s | sha256sum -c - || { echo 'Checksum mismatch for %s'; exit 1; }", quote(in.SHA256+"  "+path.Join(BatchWorkDir, in.Dest)+".part"), in.Dest),
+			fmt.Sprintf("  cp %s %s.$$ && mv %s.$$ %s", tmp, cached, cached, cached),
+			fmt.Sprintf("  mv %s %s", tmp, dest),
+			"fi",
+		)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// inputsInitContainer runs after the repository is extracted so inputs can land inside it
+func (s *Service) inputsInitContainer() corev1.Container {
+	return corev1.Container{
+		Name:    InputsContainerName,
+		Image:   s.SyncImage,
+		Command: []string{"/bin/sh", "-c"},
+		Args:    []string{inputsScript(s.Inputs)},
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      BatchVolumeName,
+				MountPath: BatchWorkDir,
+			},
+			{
+				Name:      pods.CacheVolumeName,
+				MountPath: pods.CacheMountPath,
+			},
+		},
+	}
+}
@@ -0,0 +1,38 @@
+package batch
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseInput(t *testing.T) {
+	sum := strings.Repeat("ab", 32)
+
+	input, err := ParseInput("data/ref.csv=https://example.com/ref.csv?v=2@sha256:" + sum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if input.Dest != "data/ref.csv" || input.URL != "https://example.com/ref.csv?v=2" || input.SHA256 != sum {
+		t.Errorf("unexpected input %+v", input)
+	}
+
+	input, err = ParseInput("vocab.txt=s3://bucket/models/vocab.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if input.downloadURL() != "https://bucket.s3.amazonaws.com/models/vocab.txt" {
+		t.Errorf("unexpected download url %s", input.downloadURL())
+	}
+
+	for _, value := range []string{
+		"https://example.com/file",
+		"/etc/file=https://example.com/file",
+		"../file=https://example.com/file",
+		"file=ftp://example.com/file",
+		"file=https://example.com/file@sha256:abc",
+	} {
+		if _, err := ParseInput(value); err == nil {
+			t.Errorf("expected %q to be rejected", value)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package batch
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/Quatton/qwex/apps/qwexctl/internal/pods"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/yaml"
+)
+
+// JobFile is a run definition kept next to the code it runs; see job.schema.json
+type JobFile struct {
+	Name      string            `json:"name,omitempty"`
+	Image     string            `json:"image,omitempty"`
+	Command   []string          `json:"command"`
+	Env       map[string]string `json:"env,omitempty"`
+	Inputs    []Input           `json:"inputs,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Scratch   string            `json:"scratch,omitempty"`
+	Resources *Resources        `json:"resources,omitempty"`
+	Expect    *Contract         `json:"expect,omitempty"`
+
+	// MaxDuration and TerminationGrace are Go durations, e.g. 2h30m
+	MaxDuration      string `json:"maxDuration,omitempty"`
+	TerminationGrace string `json:"terminationGrace,omitempty"`
+}
+
+// Resources override the batch container's default requests and limits
+type Resources struct {
+	CPU    string `json:"cpu,omitempty"`
+	Memory string `json:"memory,omitempty"`
+	GPU    int64  `json:"gpu,omitempty"`
+}
+
+// LoadJobFile reads and validates a job file, rejecting unknown fields so typos don't go unnoticed
+func LoadJobFile(path string) (*JobFile, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read job file: %w", err)
+	}
+
+	var f JobFile
+	if err := yaml.UnmarshalStrict(content, &f); err != nil {
+		return nil, fmt.Errorf("invalid job file %s: %w", path, err)
+	}
+	if err := f.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid job file %s: %w", path, err)
+	}
+	return &f, nil
+}
+
+func (f *JobFile) Validate() error {
+	if len(f.Command) == 0 {
+		return fmt.Errorf("command is required")
+	}
+	for _, input := range f.Inputs {
+		if err := input.Validate(); err != nil {
+			return err
+		}
+	}
+	if _, err := ParseLabels(f.LabelValues()); err != nil {
+		return err
+	}
+	if _, err := ParseScratchSize(f.Scratch); err != nil {
+		return err
+	}
+	if f.Resources != nil {
+		if err := f.Resources.Validate(); err != nil {
+			return err
+		}
+	}
+	if f.Expect != nil {
+		if err := f.Expect.Validate(); err != nil {
+			return err
+		}
+	}
+	if _, err := f.Duration(); err != nil {
+		return err
+	}
+	if _, err := f.Grace(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Duration parses MaxDuration, returning 0 if unset
+func (f *JobFile) Duration() (time.Duration, error) {
+	return parseFileDuration("maxDuration", f.MaxDuration)
+}
+
+// Grace parses TerminationGrace, returning 0 if unset
+func (f *JobFile) Grace() (time.Duration, error) {
+	return parseFileDuration("terminationGrace", f.TerminationGrace)
+}
+
+func parseFileDuration(field, value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid %s %q: expected a positive duration like 2h30m", field, value)
+	}
+	return d, ValidateSeconds(field, d)
+}
+
+// ValidateSeconds rejects durations Kubernetes would truncate to 0 seconds; 0 itself means unset
+func ValidateSeconds(name string, d time.Duration) error {
+	if d != 0 && d < time.Second {
+		return fmt.Errorf("invalid %s %s: must be at least 1s", name, d)
+	}
+	return nil
+}
+
+// EnvValues returns env as NAME=VALUE in name order, the form --env takes
+func (f *JobFile) EnvValues() []string {
+	return keyValues(f.Env)
+}
+
+// LabelValues returns labels as KEY=VALUE in key order, the form --label takes
+func (f *JobFile) LabelValues() []string {
+	return keyValues(f.Labels)
+}
+
+func keyValues(m map[string]string) []string {
+	var values []string
+	for _, key := range slices.Sorted(maps.Keys(m)) {
+		values = append(values, key+"="+m[key])
+	}
+	return values
+}
+
+func (r *Resources) Validate() error {
+	for name, value := range map[string]string{"cpu": r.CPU, "memory": r.Memory} {
+		if value == "" {
+			continue
+		}
+		q, err := resource.ParseQuantity(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q: %w", name, value, err)
+		}
+		if q.Sign() <= 0 {
+			return fmt.Errorf("invalid %s %q: must be positive", name, value)
+		}
+	}
+	if r.GPU < 0 {
+		return fmt.Errorf("invalid gpu %d: must not be negative", r.GPU)
+	}
+	return nil
+}
+
+// applyResources sets both the request and limit of each overridden resource,
+// so the job gets exactly what it asked for
+func (s *Service) applyResources(podSpec *corev1.PodSpec) {
+	if s.Resources == nil {
+		return
+	}
+
+	for i := range podSpec.Containers {
+		c := &podSpec.Containers[i]
+		if c.Name != BatchContainerName {
+			continue
+		}
+		if s.Resources.CPU != "" {
+			q := resource.MustParse(s.Resources.CPU)
+			c.Resources.Requests[corev1.ResourceCPU] = q
+			c.Resources.Limits[corev1.ResourceCPU] = q
+		}
+		if s.Resources.Memory != "" {
+			q := resource.MustParse(s.Resources.Memory)
+			c.Resources.Requests[corev1.ResourceMemory] = q
+			c.Resources.Limits[corev1.ResourceMemory] = q
+		}
+		if s.Resources.GPU > 0 {
+			c.Resources.Limits[pods.GPUResourceName] = *resource.NewQuantity(s.Resources.GPU, resource.DecimalSI)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package batch
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Quatton/qwex/apps/qwexctl/internal/pods"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func writeJobFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "job.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadJobFile(t *testing.T) {
+	path := writeJobFile(t, `
+command: [python, train.py]
+env:
+  WANDB_PROJECT: bert
+  A: "1"
+labels: {sweep: bert}
+inputs:
+  - dest: data/train.csv
+    url: s3://bucket/train.csv
+resources: {cpu: "4", memory: 16Gi, gpu: 1}
+`)
+	f, err := LoadJobFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(f.Command, []string{"python", "train.py"}) {
+		t.Errorf("unexpected command %v", f.Command)
+	}
+	if !slices.Equal(f.EnvValues(), []string{"A=1", "WANDB_PROJECT=bert"}) {
+		t.Errorf("expected env sorted by name, got %v", f.EnvValues())
+	}
+	if len(f.Inputs) != 1 || f.Inputs[0].Dest != "data/train.csv" {
+		t.Errorf("unexpected inputs %+v", f.Inputs)
+	}
+
+	invalid := map[string]string{
+		"unknown field":  "command: [ls]\nimgae: busybox\n",
+		"no command":     "image: busybox\n",
+		"reserved label": "command: [ls]\nlabels: {qwex.dev/run-id: x}\n",
+		"bad memory":     "command: [ls]\nresources: {memory: lots}\n",
+	}
+	for name, content := range invalid {
+		if _, err := LoadJobFile(writeJobFile(t, content)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestApplyResources(t *testing.T) {
+	podSpec := &corev1.PodSpec{Containers: []corev1.Container{{
+		Name: BatchContainerName,
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+			Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+		},
+	}}}
+	(&Service{Resources: &Resources{Memory: "16Gi", GPU: 1}}).applyResources(podSpec)
+
+	res := podSpec.Containers[0].Resources
+	if q := res.Requests[corev1.ResourceMemory]; q.Cmp(resource.MustParse("16Gi")) != 0 {
+		t.Errorf("expected a 16Gi memory request, got %s", q.String())
+	}
+	if q := res.Limits[pods.GPUResourceName]; q.Value() != 1 {
+		t.Errorf("expected a GPU limit of 1, got %s", q.String())
+	}
+	if q := res.Limits[corev1.ResourceCPU]; q.Cmp(resource.MustParse("2")) != 0 {
+		t.Errorf("expected the CPU default to be kept, got %s", q.String())
+	}
+}
+
+func TestJobFileDurations(t *testing.T) {
+	f := &JobFile{Command: []string{"ls"}, MaxDuration: "500ms"}
+	if err := f.Validate(); err == nil {
+		t.Error("expected a sub-second maxDuration to be rejected")
+	}
+
+	f.MaxDuration = "90m"
+	if d, err := f.Duration(); err != nil || d != 90*time.Minute {
+		t.Errorf("expected 90m, got %s, %v", d, err)
+	}
+}
+
+func TestJobFileGrace(t *testing.T) {
+	f := &JobFile{Command: []string{"ls"}, TerminationGrace: "500ms"}
+	if err := f.Validate(); err == nil {
+		t.Error("expected a sub-second terminationGrace to be rejected")
+	}
+}
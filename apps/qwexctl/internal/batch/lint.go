@@ -0,0 +1,153 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+type LintWarning struct {
+	Rule    string
+	Message string
+}
+
+func (w LintWarning) String() string {
+	return fmt.Sprintf("[%s] %s", w.Rule, w.Message)
+}
+
+// Present in practically every container, so referencing them is fine
+var wellKnownEnv = []string{"HOME", "PATH", "PWD", "USER", "HOSTNAME", "SHELL", "TERM", "LANG", "TMPDIR", "OLDPWD", "IFS", "PS1"}
+
+var (
+	envRefPattern    = regexp.MustCompile(`\$\{?([A-Za-z_][A-Za-z0-9_]*)`)
+	envAssignPattern = regexp.MustCompile(`(?:^|[\s;&|(])(?:export\s+)?([A-Za-z_][A-Za-z0-9_]*)=`)
+	forVarPattern    = regexp.MustCompile(`\bfor\s+([A-Za-z_][A-Za-z0-9_]*)\s+in\b`)
+	scriptExtensions = []string{".py", ".sh", ".ipynb", ".R", ".jl", ".js", ".ts"}
+)
+
+func isShell(name string) bool {
+	switch path.Base(name) {
+	case "sh", "bash", "zsh", "dash", "ash":
+		return true
+	}
+	return false
+}
+
+// inlineScript returns the script of `sh -c "<script>"` style invocations
+func inlineScript(argv []string) (string, bool) {
+	if len(argv) < 3 || !isShell(argv[0]) {
+		return "", false
+	}
+	for i := 1; i < len(argv)-1; i++ {
+		if argv[i] == "-c" {
+			return argv[i+1], true
+		}
+	}
+	return "", false
+}
+
+// LintCommand flags obvious foot-guns in a batch command before it takes a scheduler slot.
+// env lists the variable names the job will have set.
+func LintCommand(argv []string, workDir string, env []string, localRepoPath string) []LintWarning {
+	var warnings []LintWarning
+
+	for _, arg := range argv {
+		warnings = append(warnings, lintPath(arg, workDir, localRepoPath)...)
+	}
+
+	if script, ok := inlineScript(argv); ok {
+		warnings = append(warnings, lintScriptEnv(script, env)...)
+	}
+
+	return warnings
+}
+
+func lintPath(arg, workDir, localRepoPath string) []LintWarning {
+	if strings.HasPrefix(arg, "-") || strings.Contains(arg, " ") {
+		return nil
+	}
+
+	if localRepoPath != "" && filepath.IsAbs(arg) && strings.HasPrefix(arg, localRepoPath) {
+		return []LintWarning{{
+			Rule:    "local-path",
+			Message: fmt.Sprintf("%s is a path on your machine; inside the job the repository is at %s", arg, workDir),
+		}}
+	}
+
+	if !filepath.IsAbs(arg) && strings.Contains(arg, "..") {
+		resolved := path.Clean(path.Join(workDir, arg))
+		if resolved != workDir && !strings.HasPrefix(resolved, workDir+"/") {
+			return []LintWarning{{
+				Rule:    "outside-workdir",
+				Message: fmt.Sprintf("%s resolves to %s, outside of the job workdir %s", arg, resolved, workDir),
+			}}
+		}
+	}
+
+	if localRepoPath != "" && !filepath.IsAbs(arg) && slices.Contains(scriptExtensions, path.Ext(arg)) {
+		if _, err := os.Stat(filepath.Join(localRepoPath, arg)); err != nil {
+			return []LintWarning{{
+				Rule:    "missing-file",
+				Message: fmt.Sprintf("%s does not exist relative to the repository root, which is the job workdir", arg),
+			}}
+		}
+	}
+
+	return nil
+}
+
+func lintScriptEnv(script string, env []string) []LintWarning {
+	defined := map[string]bool{}
+	for _, name := range append(slices.Clone(wellKnownEnv), env...) {
+		defined[name] = true
+	}
+	for _, m := range envAssignPattern.FindAllStringSubmatch(script, -1) {
+		defined[m[1]] = true
+	}
+	for _, m := range forVarPattern.FindAllStringSubmatch(script, -1) {
+		defined[m[1]] = true
+	}
+
+	var warnings []LintWarning
+	reported := map[string]bool{}
+	for _, m := range envRefPattern.FindAllStringSubmatch(script, -1) {
+		name := m[1]
+		if defined[name] || reported[name] {
+			continue
+		}
+		reported[name] = true
+		warnings = append(warnings, LintWarning{
+			Rule:    "undefined-env",
+			Message: fmt.Sprintf("$%s is referenced but not set in the job environment", name),
+		})
+	}
+	return warnings
+}
+
+// ProbeCommand checks that the command exists on PATH in a running container of the same image
+func (s *Service) ProbeCommand(ctx context.Context, containerName string) *LintWarning {
+	if len(s.Command) == 0 {
+		return nil
+	}
+
+	_, err := s.connector.RemoteExecContainer(ctx, []string{"/bin/sh", "-c", `command -v "$0"`, s.Command[0]}, nil, containerName)
+
+	// Only a non-zero exit means "not found"; connection problems are not the command's fault
+	var exitErr utilexec.ExitError
+	if !errors.As(err, &exitErr) {
+		return nil
+	}
+
+	return &LintWarning{
+		Rule:    "command-not-found",
+		Message: fmt.Sprintf("%s was not found on PATH in image %s", s.Command[0], s.Image),
+	}
+}
@@ -0,0 +1,65 @@
+package batch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func lintRules(warnings []LintWarning) map[string]int {
+	rules := map[string]int{}
+	for _, w := range warnings {
+		rules[w.Rule]++
+	}
+	return rules
+}
+
+func TestLintCommand(t *testing.T) {
+	repo := t.TempDir()
+	if err := os.WriteFile(filepath.Join(repo, "train.py"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		argv     []string
+		expected map[string]int
+	}{
+		"clean": {
+			argv:     []string{"python", "train.py", "--lr", "0.1"},
+			expected: map[string]int{},
+		},
+		"missing script": {
+			argv:     []string{"python", "trian.py"},
+			expected: map[string]int{"missing-file": 1},
+		},
+		"escapes workdir": {
+			argv:     []string{"cat", "../../etc/passwd"},
+			expected: map[string]int{"outside-workdir": 1},
+		},
+		"local absolute path": {
+			argv:     []string{"python", filepath.Join(repo, "train.py")},
+			expected: map[string]int{"local-path": 1},
+		},
+		"undefined env": {
+			argv:     []string{"sh", "-c", "OUT=/tmp/x; echo $OUT $WANDB_API_KEY ${WANDB_API_KEY} $HOME $XDG_CACHE_HOME"},
+			expected: map[string]int{"undefined-env": 1},
+		},
+		"loop variable": {
+			argv:     []string{"bash", "-c", "for seed in 1 2 3; do python train.py --seed $seed; done"},
+			expected: map[string]int{},
+		},
+	}
+
+	for name, tc := range cases {
+		actual := lintRules(LintCommand(tc.argv, BatchWorkDir, []string{"XDG_CACHE_HOME"}, repo))
+		if len(actual) != len(tc.expected) {
+			t.Errorf("%s: expected %v, got %v", name, tc.expected, actual)
+			continue
+		}
+		for rule, count := range tc.expected {
+			if actual[rule] != count {
+				t.Errorf("%s: expected %d %s warnings, got %d", name, count, rule, actual[rule])
+			}
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package batch
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	timestampPattern = regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?|\b\d{2}:\d{2}:\d{2}(?:[.,]\d+)?\b`)
+	hexIDPattern     = regexp.MustCompile(`\b(?:0x)?[0-9a-f]{8,}\b`)
+	numberPattern    = regexp.MustCompile(`-?\d+(?:\.\d+)?(?:[eE][-+]?\d+)?`)
+)
+
+// Logs larger than this in the differing middle section are not aligned line by line
+const maxLogDiffCells = 25_000_000
+
+// bucketNumber keeps the sign and order of magnitude, so 0.912 and 0.934 compare equal but 0.9 and 9.1 don't
+func bucketNumber(s string) string {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return s
+	}
+	if v == 0 {
+		return "0"
+	}
+	sign := ""
+	if v < 0 {
+		sign = "-"
+	}
+	return fmt.Sprintf("%s1e%d", sign, int(math.Floor(math.Log10(math.Abs(v)))))
+}
+
+// NormalizeLogLine strips the parts of a line that differ between otherwise identical runs
+func NormalizeLogLine(line string) string {
+	line = timestampPattern.ReplaceAllString(line, "<time>")
+	line = hexIDPattern.ReplaceAllStringFunc(line, func(id string) string {
+		// All-digit runs are numbers, bucketed below
+		if strings.IndexAny(strings.TrimPrefix(id, "0x"), "abcdef") < 0 {
+			return id
+		}
+		return "<id>"
+	})
+	line = numberPattern.ReplaceAllStringFunc(line, bucketNumber)
+	return strings.TrimRight(line, " \t\r")
+}
+
+type LogDiffOp byte
+
+const (
+	LogEqual  LogDiffOp = ' '
+	LogRemove LogDiffOp = '-'
+	LogAdd    LogDiffOp = '+'
+)
+
+type LogDiffLine struct {
+	Op   LogDiffOp
+	Text string
+	// 1-based line numbers in the reference (A) and compared (B) logs, 0 when absent
+	LineA int
+	LineB int
+}
+
+// DiffLogs aligns normalized lines of a and b; Text holds the original line
+func DiffLogs(a, b []string) []LogDiffLine {
+	na := make([]string, len(a))
+	for i, l := range a {
+		na[i] = NormalizeLogLine(l)
+	}
+	nb := make([]string, len(b))
+	for i, l := range b {
+		nb[i] = NormalizeLogLine(l)
+	}
+
+	prefix := 0
+	for prefix < len(na) && prefix < len(nb) && na[prefix] == nb[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(na)-prefix && suffix < len(nb)-prefix && na[len(na)-1-suffix] == nb[len(nb)-1-suffix] {
+		suffix++
+	}
+
+	var out []LogDiffLine
+	for i := 0; i < prefix; i++ {
+		out = append(out, LogDiffLine{Op: LogEqual, Text: b[i], LineA: i + 1, LineB: i + 1})
+	}
+
+	midA, midB := na[prefix:len(na)-suffix], nb[prefix:len(nb)-suffix]
+	if len(midA)*len(midB) <= maxLogDiffCells {
+		out = append(out, lcsDiff(a, b, midA, midB, prefix)...)
+	} else {
+		for i := range midA {
+			out = append(out, LogDiffLine{Op: LogRemove, Text: a[prefix+i], LineA: prefix + i + 1})
+		}
+		for j := range midB {
+			out = append(out, LogDiffLine{Op: LogAdd, Text: b[prefix+j], LineB: prefix + j + 1})
+		}
+	}
+
+	for k := suffix; k > 0; k-- {
+		out = append(out, LogDiffLine{Op: LogEqual, Text: b[len(b)-k], LineA: len(a) - k + 1, LineB: len(b) - k + 1})
+	}
+	return out
+}
+
+func lcsDiff(a, b, na, nb []string, offset int) []LogDiffLine {
+	n, m := len(na), len(nb)
+	lcs := make([][]int32, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if na[i] == nb[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var out []LogDiffLine
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && na[i] == nb[j]:
+			out = append(out, LogDiffLine{Op: LogEqual, Text: b[offset+j], LineA: offset + i + 1, LineB: offset + j + 1})
+			i++
+			j++
+		case j < m && (i == n || lcs[i][j+1] >= lcs[i+1][j]):
+			out = append(out, LogDiffLine{Op: LogAdd, Text: b[offset+j], LineB: offset + j + 1})
+			j++
+		default:
+			out = append(out, LogDiffLine{Op: LogRemove, Text: a[offset+i], LineA: offset + i + 1})
+			i++
+		}
+	}
+	return out
+}
+
+// FirstDivergence returns the index of the first non-equal line, or -1 if the logs match
+func FirstDivergence(diff []LogDiffLine) int {
+	for i, l := range diff {
+		if l.Op != LogEqual {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,42 @@
+package batch
+
+import "testing"
+
+func TestNormalizeLogLine(t *testing.T) {
+	a := NormalizeLogLine("2025-01-01T10:00:00Z step 120 loss=0.912 run 3f9a2c1de0")
+	b := NormalizeLogLine("2025-01-02T11:30:12Z step 180 loss=0.934 run 77ab01c9ff")
+	if a != b {
+		t.Errorf("expected lines to normalize equally:\n%s\n%s", a, b)
+	}
+	if NormalizeLogLine("loss=0.9") == NormalizeLogLine("loss=9.1") {
+		t.Error("expected different orders of magnitude to stay distinct")
+	}
+}
+
+func TestDiffLogs(t *testing.T) {
+	good := []string{"10:00:01 loading data", "10:00:02 epoch 1 loss=0.5", "10:00:03 epoch 2 loss=0.4", "10:00:04 done"}
+	bad := []string{"11:00:01 loading data", "11:00:02 epoch 1 loss=0.5", "11:00:03 loss=nan", "11:00:04 done"}
+
+	diff := DiffLogs(good, bad)
+	first := FirstDivergence(diff)
+	if first < 0 {
+		t.Fatal("expected a divergence")
+	}
+	if diff[first].Op != LogAdd || diff[first].LineB != 3 {
+		t.Errorf("unexpected first divergence %+v", diff[first])
+	}
+
+	changed := 0
+	for _, l := range diff {
+		if l.Op != LogEqual {
+			changed++
+		}
+	}
+	if changed != 2 {
+		t.Errorf("expected 2 changed lines, got %d: %+v", changed, diff)
+	}
+
+	if FirstDivergence(DiffLogs(good, good)) != -1 {
+		t.Error("expected identical logs to match")
+	}
+}
@@ -0,0 +1,39 @@
+package batch
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// LogRange selects part of a run's logs; zero fields are unbounded
+type LogRange struct {
+	// TailLines returns only the last N lines
+	TailLines int64
+	// Since returns only lines newer than this
+	Since time.Duration
+	// LimitBytes stops reading after this many bytes, guarding against huge logs
+	LimitBytes int64
+}
+
+func (r LogRange) Validate() error {
+	if r.TailLines < 0 || r.Since < 0 || r.LimitBytes < 0 {
+		return fmt.Errorf("log range values must not be negative")
+	}
+	// The API takes whole seconds, so a shorter --since would select everything
+	return ValidateSeconds("--since", r.Since)
+}
+
+func (r LogRange) apply(opts *corev1.PodLogOptions) {
+	if r.TailLines > 0 {
+		opts.TailLines = &r.TailLines
+	}
+	if r.Since > 0 {
+		seconds := int64(r.Since.Seconds())
+		opts.SinceSeconds = &seconds
+	}
+	if r.LimitBytes > 0 {
+		opts.LimitBytes = &r.LimitBytes
+	}
+}
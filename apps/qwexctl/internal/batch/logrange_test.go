@@ -0,0 +1,22 @@
+package batch
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLogRangeValidate(t *testing.T) {
+	valid := []LogRange{{}, {Since: time.Second}, {TailLines: 10, Since: 10 * time.Minute, LimitBytes: 1024}}
+	for _, r := range valid {
+		if err := r.Validate(); err != nil {
+			t.Errorf("%+v: unexpected error %v", r, err)
+		}
+	}
+
+	invalid := []LogRange{{TailLines: -1}, {Since: -time.Minute}, {Since: 500 * time.Millisecond}}
+	for _, r := range invalid {
+		if err := r.Validate(); err == nil {
+			t.Errorf("%+v: expected an error", r)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package batch
+
+import (
+	"fmt"
+	"strings"
+)
+
+const MatrixLabel = "qwex.dev/matrix"
+
+// MatrixAxis is one --matrix parameter and the values it takes
+type MatrixAxis struct {
+	Name   string
+	Values []string
+}
+
+// ParseMatrix parses NAME=v1,v2,... axes
+func ParseMatrix(values []string) ([]MatrixAxis, error) {
+	var axes []MatrixAxis
+	seen := map[string]bool{}
+	for _, value := range values {
+		name, list, ok := strings.Cut(value, "=")
+		if !ok || !envNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid matrix %q: expected NAME=v1,v2,...", value)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("matrix parameter %s given more than once", name)
+		}
+		seen[name] = true
+
+		var vals []string
+		for _, v := range strings.Split(list, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				vals = append(vals, v)
+			}
+		}
+		if len(vals) == 0 {
+			return nil, fmt.Errorf("matrix parameter %s has no values", name)
+		}
+		axes = append(axes, MatrixAxis{Name: name, Values: vals})
+	}
+	return axes, nil
+}
+
+// ExpandMatrix returns every combination, varying the last axis fastest
+func ExpandMatrix(axes []MatrixAxis) [][]EnvVar {
+	if len(axes) == 0 {
+		return nil
+	}
+	combos := [][]EnvVar{nil}
+	for _, axis := range axes {
+		var next [][]EnvVar
+		for _, combo := range combos {
+			for _, v := range axis.Values {
+				c := append(append([]EnvVar{}, combo...), EnvVar{Name: axis.Name, Value: v})
+				next = append(next, c)
+			}
+		}
+		combos = next
+	}
+	return combos
+}
+
+func FormatParams(params []EnvVar) string {
+	parts := make([]string, len(params))
+	for i, p := range params {
+		parts[i] = p.Name + "=" + p.Value
+	}
+	return strings.Join(parts, " ")
+}
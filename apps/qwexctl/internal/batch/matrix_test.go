@@ -0,0 +1,27 @@
+package batch
+
+import "testing"
+
+func TestExpandMatrix(t *testing.T) {
+	axes, err := ParseMatrix([]string{"LR=0.1,0.01", "SEED=1,2,3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	combos := ExpandMatrix(axes)
+	if len(combos) != 6 {
+		t.Fatalf("expected 6 combinations, got %d", len(combos))
+	}
+	if actual := FormatParams(combos[0]); actual != "LR=0.1 SEED=1" {
+		t.Errorf("unexpected first combination %s", actual)
+	}
+	if actual := FormatParams(combos[5]); actual != "LR=0.01 SEED=3" {
+		t.Errorf("unexpected last combination %s", actual)
+	}
+
+	for _, values := range [][]string{{"lr"}, {"1lr=1"}, {"LR=,"}, {"LR=1", "LR=2"}} {
+		if _, err := ParseMatrix(values); err == nil {
+			t.Errorf("expected %v to be rejected", values)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package batch
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	PipelineLabel = "qwex.dev/pipeline"
+	StepLabel     = "qwex.dev/step"
+
+	// AllSteps runs every step of the pipeline
+	AllSteps = "all"
+)
+
+// PipelineStep is a job file plus the steps that must succeed before it runs
+type PipelineStep struct {
+	JobFile
+	Needs []string `json:"needs,omitempty"`
+}
+
+// LoadPipeline reads the jobs: section of a project config; other keys are left to viper
+func LoadPipeline(path string) (map[string]PipelineStep, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var config struct {
+		Jobs map[string]PipelineStep `json:"jobs"`
+	}
+	if err := yaml.Unmarshal(content, &config); err != nil {
+		return nil, fmt.Errorf("invalid jobs in %s: %w", path, err)
+	}
+	if len(config.Jobs) == 0 {
+		return nil, fmt.Errorf("no jobs defined in %s", path)
+	}
+
+	for name, step := range config.Jobs {
+		if name == AllSteps {
+			return nil, fmt.Errorf("invalid job name %q in %s: reserved for running every job", name, path)
+		}
+		if err := step.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid job %s in %s: %w", name, path, err)
+		}
+		for _, need := range step.Needs {
+			if _, ok := config.Jobs[need]; !ok {
+				return nil, fmt.Errorf("job %s needs unknown job %s", name, need)
+			}
+		}
+	}
+	return config.Jobs, nil
+}
+
+// PipelineOrder returns target and everything it needs, dependencies first.
+// Independent steps run in name order so the order is stable between runs.
+func PipelineOrder(steps map[string]PipelineStep, target string) ([]string, error) {
+	roots := []string{target}
+	if target == AllSteps {
+		roots = slices.Sorted(maps.Keys(steps))
+	} else if _, ok := steps[target]; !ok {
+		return nil, fmt.Errorf("unknown job %s (defined: %s)", target, strings.Join(slices.Sorted(maps.Keys(steps)), ", "))
+	}
+
+	var order []string
+	state := map[string]int{} // 1 while visiting, 2 once ordered
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case 1:
+			return fmt.Errorf("jobs depend on each other in a cycle: %s", strings.Join(append(path, name), " -> "))
+		case 2:
+			return nil
+		}
+		state[name] = 1
+		for _, need := range slices.Sorted(slices.Values(steps[name].Needs)) {
+			if err := visit(need, append(path, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = 2
+		order = append(order, name)
+		return nil
+	}
+
+	for _, root := range roots {
+		if err := visit(root, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
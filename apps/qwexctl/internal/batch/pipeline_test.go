@@ -0,0 +1,67 @@
+package batch
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPipelineOrder(t *testing.T) {
+	step := func(needs ...string) PipelineStep {
+		return PipelineStep{JobFile: JobFile{Command: []string{"true"}}, Needs: needs}
+	}
+	steps := map[string]PipelineStep{
+		"prepare":  step(),
+		"train":    step("prepare"),
+		"evaluate": step("train", "prepare"),
+		"lint":     step(),
+	}
+
+	order, err := PipelineOrder(steps, "evaluate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"prepare", "train", "evaluate"}; !slices.Equal(order, expected) {
+		t.Errorf("expected %v, got %v", expected, order)
+	}
+
+	order, err = PipelineOrder(steps, AllSteps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"prepare", "train", "evaluate", "lint"}; !slices.Equal(order, expected) {
+		t.Errorf("expected %v, got %v", expected, order)
+	}
+
+	if _, err := PipelineOrder(steps, "deploy"); err == nil {
+		t.Error("expected an unknown job to be rejected")
+	}
+
+	steps["prepare"] = step("evaluate")
+	if _, err := PipelineOrder(steps, "train"); err == nil {
+		t.Error("expected a cycle to be rejected")
+	}
+}
+
+func TestLoadPipeline(t *testing.T) {
+	path := writeJobFile(t, `
+namespace: ml
+jobs:
+  prepare:
+    command: [python, prepare.py]
+  train:
+    command: [python, train.py]
+    needs: [prepare]
+    env: {EPOCHS: "10"}
+`)
+	steps, err := LoadPipeline(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(steps["train"].Needs, []string{"prepare"}) || steps["train"].Env["EPOCHS"] != "10" {
+		t.Errorf("unexpected train step %+v", steps["train"])
+	}
+
+	if _, err := LoadPipeline(writeJobFile(t, "jobs:\n  train:\n    command: [ls]\n    needs: [prepare]\n")); err == nil {
+		t.Error("expected an unknown dependency to be rejected")
+	}
+}
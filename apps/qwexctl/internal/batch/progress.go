@@ -0,0 +1,98 @@
+package batch
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// RunPhase describes where a run is on its way to running, for progress output
+func RunPhase(pod *corev1.Pod) string {
+	if pod == nil {
+		return "queued"
+	}
+
+	switch pod.Status.Phase {
+	case corev1.PodSucceeded:
+		return "succeeded"
+	case corev1.PodFailed:
+		return "failed"
+	}
+
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodScheduled && c.Status == corev1.ConditionFalse {
+			return "waiting for a node"
+		}
+	}
+
+	for _, cs := range pod.Status.InitContainerStatuses {
+		if cs.State.Terminated != nil {
+			continue
+		}
+		if cs.State.Waiting != nil && isImagePullProblem(cs.State.Waiting.Reason) {
+			return fmt.Sprintf("image pull failing (%s)", cs.State.Waiting.Reason)
+		}
+		if cs.Name == InputsContainerName {
+			return "downloading inputs"
+		}
+		return "preparing workspace"
+	}
+
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.Name != BatchContainerName {
+			continue
+		}
+		switch {
+		case cs.State.Running != nil:
+			return "running"
+		case cs.State.Waiting != nil && isImagePullProblem(cs.State.Waiting.Reason):
+			return fmt.Sprintf("image pull failing (%s)", cs.State.Waiting.Reason)
+		case cs.State.Waiting != nil:
+			return "pulling image"
+		}
+	}
+	return "starting"
+}
+
+func isImagePullProblem(reason string) bool {
+	return reason == "ErrImagePull" || reason == "ImagePullBackOff" || reason == "InvalidImageName"
+}
+
+// WaitForRunFinished waits briefly for the job to record its outcome after logs end
+func (s *Service) WaitForRunFinished(ctx context.Context, runID string, timeout time.Duration) (*v1.Job, error) {
+	var job *v1.Job
+	err := wait.PollUntilContextTimeout(ctx, time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		var err error
+		job, err = s.GetRunJob(ctx, runID)
+		if err != nil {
+			return false, err
+		}
+		return IsJobFinished(job), nil
+	})
+	if job != nil && wait.Interrupted(err) {
+		return job, nil
+	}
+	return job, err
+}
+
+// RunDuration is how long the run has been or was executing
+func RunDuration(job *v1.Job) time.Duration {
+	if job.Status.StartTime == nil {
+		return 0
+	}
+	end := time.Now()
+	if job.Status.CompletionTime != nil {
+		end = job.Status.CompletionTime.Time
+	} else if IsJobFinished(job) {
+		for _, c := range job.Status.Conditions {
+			if c.Type == v1.JobFailed {
+				end = c.LastTransitionTime.Time
+			}
+		}
+	}
+	return end.Sub(job.Status.StartTime.Time).Round(time.Second)
+}
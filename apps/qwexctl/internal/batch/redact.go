@@ -0,0 +1,127 @@
+package batch
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const redactedText = "[REDACTED]"
+
+// Shorter secret values would mask ordinary words and numbers
+const minRedactedSecretLength = 6
+
+// DefaultRedactPatterns match well-known token formats
+var DefaultRedactPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+	regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`),
+	regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{22,}\b`),
+	regexp.MustCompile(`\bhf_[A-Za-z0-9]{30,}\b`),
+	regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{20,}\b`),
+	regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`),
+	regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/-]{20,}=*`),
+}
+
+// Longer lines are redacted and written in pieces, so a token straddling
+// a piece boundary isn't masked
+const maxRedactLineLength = 64 * 1024
+
+// RedactWriter masks matches line by line; call Flush when the stream ends.
+// \r ends a line too, so progress bars that redraw in place keep updating.
+type RedactWriter struct {
+	w        io.Writer
+	patterns []*regexp.Regexp
+	pending  []byte
+}
+
+func NewRedactWriter(w io.Writer, patterns []*regexp.Regexp) *RedactWriter {
+	return &RedactWriter{w: w, patterns: patterns}
+}
+
+func (r *RedactWriter) redact(line []byte) []byte {
+	for _, p := range r.patterns {
+		line = p.ReplaceAll(line, []byte(redactedText))
+	}
+	return line
+}
+
+func (r *RedactWriter) Write(p []byte) (int, error) {
+	r.pending = append(r.pending, p...)
+	for {
+		i := bytes.IndexAny(r.pending, "\r\n")
+		if i < 0 {
+			break
+		}
+		if _, err := r.w.Write(r.redact(r.pending[:i+1])); err != nil {
+			return 0, err
+		}
+		r.pending = r.pending[i+1:]
+	}
+	if len(r.pending) > maxRedactLineLength {
+		if err := r.Flush(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (r *RedactWriter) Flush() error {
+	if len(r.pending) == 0 {
+		return nil
+	}
+	_, err := r.w.Write(r.redact(r.pending))
+	r.pending = nil
+	return err
+}
+
+// secretPatterns matches the values of secrets injected into the batch container.
+// This needs get on secrets in the namespace. Secrets we can't read, e.g. because
+// RBAC denies it, are silently skipped: the job still gets them, we just can't mask them.
+func (s *Service) secretPatterns(ctx context.Context, pod *corev1.Pod) []*regexp.Regexp {
+	var patterns []*regexp.Regexp
+	secrets := map[string]*corev1.Secret{}
+
+	for _, c := range pod.Spec.Containers {
+		if c.Name != BatchContainerName {
+			continue
+		}
+		for _, e := range c.Env {
+			if e.ValueFrom == nil || e.ValueFrom.SecretKeyRef == nil {
+				continue
+			}
+			ref := e.ValueFrom.SecretKeyRef
+
+			secret, ok := secrets[ref.Name]
+			if !ok {
+				secret, _ = s.connector.Client.CoreV1().Secrets(s.connector.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+				secrets[ref.Name] = secret
+			}
+			if secret == nil {
+				continue
+			}
+
+			if value := secret.Data[ref.Key]; len(value) >= minRedactedSecretLength {
+				patterns = append(patterns, regexp.MustCompile(regexp.QuoteMeta(string(value))))
+			}
+		}
+	}
+	return patterns
+}
+
+// ParseRedactPatterns compiles user-configured patterns
+func ParseRedactPatterns(exprs []string) ([]*regexp.Regexp, error) {
+	var patterns []*regexp.Regexp
+	for _, expr := range exprs {
+		p, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact pattern %q: %w", expr, err)
+		}
+		patterns = append(patterns, p)
+	}
+	return patterns, nil
+}
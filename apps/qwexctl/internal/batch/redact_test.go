@@ -0,0 +1,48 @@
+package batch
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+)
+
+func TestRedactWriter(t *testing.T) {
+	var out bytes.Buffer
+	w := NewRedactWriter(&out, append(DefaultRedactPatterns, regexp.MustCompile(regexp.QuoteMeta("hunter2hunter2"))))
+
+	// Split mid-token to check matches across writes
+	chunks := []string{"key=AKIAABCDEFGH", "IJKLMNOP ok\npassword hunter2", "hunter2\nstep 1 loss 0.5"}
+	for _, c := range chunks {
+		if _, err := w.Write([]byte(c)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "key=[REDACTED] ok\npassword [REDACTED]\nstep 1 loss 0.5"
+	if out.String() != expected {
+		t.Errorf("expected %q, got %q", expected, out.String())
+	}
+}
+
+func TestRedactWriterCarriageReturn(t *testing.T) {
+	var out bytes.Buffer
+	w := NewRedactWriter(&out, DefaultRedactPatterns)
+
+	if _, err := w.Write([]byte("\r 10%|#   |\r 20%|##  |\r 30%")); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "\r 10%|#   |\r 20%|##  |\r"; out.String() != expected {
+		t.Errorf("expected progress updates to be written before a newline, got %q", out.String())
+	}
+
+	out.Reset()
+	if _, err := w.Write(bytes.Repeat([]byte("x"), maxRedactLineLength+1)); err != nil {
+		t.Fatal(err)
+	}
+	if out.Len() != maxRedactLineLength+1+len(" 30%") {
+		t.Errorf("expected an overlong line to be written out, got %d bytes", out.Len())
+	}
+}
@@ -0,0 +1,99 @@
+package batch
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/batch/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	TypeLabel = "qwex.dev/type"
+
+	reservedLabelPrefix = "qwex.dev/"
+)
+
+// ParseLabels parses KEY=VALUE user labels, which may not use the qwex.dev/ prefix
+func ParseLabels(values []string) (map[string]string, error) {
+	result := map[string]string{}
+	for _, value := range values {
+		key, val, ok := strings.Cut(value, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid label %q: expected KEY=VALUE", value)
+		}
+		if strings.HasPrefix(key, reservedLabelPrefix) {
+			return nil, fmt.Errorf("invalid label %q: the %s prefix is reserved", value, reservedLabelPrefix)
+		}
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid label key %q: %s", key, strings.Join(errs, "; "))
+		}
+		if errs := validation.IsValidLabelValue(val); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid label value %q: %s", val, strings.Join(errs, "; "))
+		}
+		result[key] = val
+	}
+	return result, nil
+}
+
+// ListRuns returns batch runs matching a label selector, newest first
+func (s *Service) ListRuns(ctx context.Context, selector string) ([]v1.Job, error) {
+	parsed, err := labels.Parse(selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector %q: %w", selector, err)
+	}
+	batchOnly, _ := labels.Parse(TypeLabel + "=batch")
+	requirements, _ := batchOnly.Requirements()
+
+	jobList, err := s.connector.Client.BatchV1().Jobs(s.connector.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: parsed.Add(requirements...).String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list runs: %w", err)
+	}
+
+	jobs := jobList.Items
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[j].CreationTimestamp.Before(&jobs[i].CreationTimestamp)
+	})
+	return jobs, nil
+}
+
+func (s *Service) CancelJob(ctx context.Context, job *v1.Job) error {
+	prop := metav1.DeletePropagationBackground
+	err := s.connector.Client.BatchV1().Jobs(s.connector.Namespace).Delete(ctx, job.Name, metav1.DeleteOptions{
+		PropagationPolicy: &prop,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to cancel job %s: %w", job.Name, err)
+	}
+	return nil
+}
+
+// ForceCancelJob cancels the job and kills its pods right away, skipping their cleanup grace period
+func (s *Service) ForceCancelJob(ctx context.Context, job *v1.Job) error {
+	if err := s.CancelJob(ctx, job); err != nil {
+		return err
+	}
+
+	podList, err := s.connector.Client.CoreV1().Pods(s.connector.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", RunIDLabel, job.Labels[RunIDLabel]),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list pods of job %s: %w", job.Name, err)
+	}
+
+	zero := int64(0)
+	for _, pod := range podList.Items {
+		err := s.connector.Client.CoreV1().Pods(s.connector.Namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{GracePeriodSeconds: &zero})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to kill pod %s: %w", pod.Name, err)
+		}
+	}
+	return nil
+}
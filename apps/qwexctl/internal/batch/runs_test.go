@@ -0,0 +1,52 @@
+package batch
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Quatton/qwex/apps/qwexctl/internal/connect"
+	v1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestParseLabels(t *testing.T) {
+	labels, err := ParseLabels([]string{"sweep=bert", "team.example.com/owner=ml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if labels["sweep"] != "bert" || labels["team.example.com/owner"] != "ml" {
+		t.Errorf("unexpected labels %v", labels)
+	}
+
+	for _, value := range []string{"sweep", "qwex.dev/run-id=x", "bad key=x", "sweep=has space"} {
+		if _, err := ParseLabels([]string{value}); err == nil {
+			t.Errorf("expected %q to be rejected", value)
+		}
+	}
+}
+
+func TestForceCancelJob(t *testing.T) {
+	labels := map[string]string{TypeLabel: "batch", RunIDLabel: "abc"}
+	job := &v1.Job{ObjectMeta: metav1.ObjectMeta{Name: "job-abc", Namespace: "ns", Labels: labels}}
+	client := fake.NewSimpleClientset(
+		job,
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "job-abc-pod", Namespace: "ns", Labels: labels}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "other-pod", Namespace: "ns", Labels: map[string]string{RunIDLabel: "xyz"}}},
+	)
+	s := NewService(&connect.Service{Client: client, Namespace: "ns"}, "", "", nil, nil, "", "")
+
+	if err := s.ForceCancelJob(context.Background(), job); err != nil {
+		t.Fatal(err)
+	}
+
+	jobs, _ := client.BatchV1().Jobs("ns").List(context.Background(), metav1.ListOptions{})
+	if len(jobs.Items) != 0 {
+		t.Errorf("expected the job to be deleted, got %d", len(jobs.Items))
+	}
+	pods, _ := client.CoreV1().Pods("ns").List(context.Background(), metav1.ListOptions{})
+	if len(pods.Items) != 1 || pods.Items[0].Name != "other-pod" {
+		t.Errorf("expected only the run's pods to be killed, got %v", pods.Items)
+	}
+}
@@ -0,0 +1,122 @@
+package batch
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ScratchVolumeName = "scratch"
+	ScratchMountPath  = "/scratch"
+
+	// DiskQuotaExceeded is reported for runs evicted for going over their scratch size
+	DiskQuotaExceeded = "disk_quota_exceeded"
+)
+
+func ParseScratchSize(value string) (*resource.Quantity, error) {
+	if value == "" {
+		return nil, nil
+	}
+	q, err := resource.ParseQuantity(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid scratch size %q: %w", value, err)
+	}
+	if q.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid scratch size %q: must be positive", value)
+	}
+	return &q, nil
+}
+
+// applyScratch gives the batch container a size-limited /scratch and caps its ephemeral storage,
+// so the kubelet evicts a runaway job instead of filling the node's disk
+func (s *Service) applyScratch(podSpec *corev1.PodSpec) {
+	if s.Scratch == nil {
+		return
+	}
+
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: ScratchVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{SizeLimit: s.Scratch},
+		},
+	})
+
+	for i := range podSpec.Containers {
+		c := &podSpec.Containers[i]
+		if c.Name != BatchContainerName {
+			continue
+		}
+		c.VolumeMounts = append(c.VolumeMounts, corev1.VolumeMount{
+			Name:      ScratchVolumeName,
+			MountPath: ScratchMountPath,
+		})
+		c.Env = append(c.Env, corev1.EnvVar{Name: "TMPDIR", Value: ScratchMountPath})
+		c.Resources.Requests[corev1.ResourceEphemeralStorage] = *s.Scratch
+		c.Resources.Limits[corev1.ResourceEphemeralStorage] = *s.Scratch
+	}
+}
+
+// FailureReason explains why a run's pod failed, or returns "" if it didn't
+func FailureReason(pod *corev1.Pod) string {
+	if pod.Status.Phase != corev1.PodFailed {
+		return ""
+	}
+	if pod.Status.Reason == "Evicted" {
+		msg := strings.ToLower(pod.Status.Message)
+		if strings.Contains(msg, "ephemeral") || strings.Contains(msg, "emptydir") {
+			return DiskQuotaExceeded
+		}
+		return "evicted"
+	}
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.Name == BatchContainerName && cs.State.Terminated != nil {
+			if strings.HasPrefix(cs.State.Terminated.Message, ContractViolation) {
+				return ContractViolation
+			}
+			if cs.State.Terminated.Reason != "" {
+				return cs.State.Terminated.Reason
+			}
+			return fmt.Sprintf("exit code %d", cs.State.Terminated.ExitCode)
+		}
+	}
+	return pod.Status.Reason
+}
+
+// RunFailedError is a run that failed for a reason other than its command's exit
+// code: Reason is DiskQuotaExceeded or ContractViolation
+type RunFailedError struct {
+	Reason  string
+	Message string
+}
+
+func (e *RunFailedError) Error() string {
+	if e.Reason == DiskQuotaExceeded {
+		return fmt.Sprintf("run exceeded its scratch size (%s): %s", DiskQuotaExceeded, e.Message)
+	}
+	return fmt.Sprintf("run did not meet its contract: %s", e.Message)
+}
+
+// checkRunFailure turns a disk quota eviction or contract violation into a
+// *RunFailedError, since neither shows up as a normal command failure
+func (s *Service) checkRunFailure(ctx context.Context, podName string) error {
+	pod, err := s.connector.Client.CoreV1().Pods(s.connector.Namespace).Get(ctx, podName, metav1.GetOptions{})
+	if err != nil {
+		return nil
+	}
+	switch FailureReason(pod) {
+	case DiskQuotaExceeded:
+		return &RunFailedError{Reason: DiskQuotaExceeded, Message: pod.Status.Message}
+	case ContractViolation:
+		for _, cs := range pod.Status.ContainerStatuses {
+			if cs.Name == BatchContainerName && cs.State.Terminated != nil {
+				return &RunFailedError{Reason: ContractViolation, Message: strings.TrimSpace(cs.State.Terminated.Message)}
+			}
+		}
+	}
+	return nil
+}
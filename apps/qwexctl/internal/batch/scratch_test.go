@@ -0,0 +1,58 @@
+package batch
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestApplyScratch(t *testing.T) {
+	scratch, err := ParseScratchSize("20Gi")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	podSpec := &corev1.PodSpec{Containers: []corev1.Container{{
+		Name: BatchContainerName,
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{},
+			Limits:   corev1.ResourceList{},
+		},
+	}}}
+	(&Service{Scratch: scratch}).applyScratch(podSpec)
+
+	if len(podSpec.Volumes) != 1 || podSpec.Volumes[0].EmptyDir.SizeLimit.Cmp(resource.MustParse("20Gi")) != 0 {
+		t.Errorf("expected a 20Gi scratch emptyDir, got %+v", podSpec.Volumes)
+	}
+	limit := podSpec.Containers[0].Resources.Limits[corev1.ResourceEphemeralStorage]
+	if limit.Cmp(resource.MustParse("20Gi")) != 0 {
+		t.Errorf("expected a 20Gi ephemeral-storage limit, got %s", limit.String())
+	}
+
+	if _, err := ParseScratchSize("-1Gi"); err == nil {
+		t.Error("expected a negative size to be rejected")
+	}
+}
+
+func TestFailureReason(t *testing.T) {
+	evicted := &corev1.Pod{Status: corev1.PodStatus{
+		Phase:   corev1.PodFailed,
+		Reason:  "Evicted",
+		Message: "Usage of EmptyDir volume \"scratch\" exceeds the limit \"20Gi\".",
+	}}
+	if reason := FailureReason(evicted); reason != DiskQuotaExceeded {
+		t.Errorf("expected %s, got %s", DiskQuotaExceeded, reason)
+	}
+
+	crashed := &corev1.Pod{Status: corev1.PodStatus{
+		Phase: corev1.PodFailed,
+		ContainerStatuses: []corev1.ContainerStatus{{
+			Name:  BatchContainerName,
+			State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 137, Reason: "OOMKilled"}},
+		}},
+	}}
+	if reason := FailureReason(crashed); reason != "OOMKilled" {
+		t.Errorf("expected OOMKilled, got %s", reason)
+	}
+}
@@ -0,0 +1,145 @@
+package batch
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/Quatton/qwex/apps/qwexctl/internal/pods"
+	v1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ScriptVolumeName = "script"
+	ScriptMountPath  = "/qwex-script"
+
+	// ConfigMaps cap out at 1MiB including metadata
+	MaxScriptSize = 900 * 1024
+
+	// StdinScriptName is what a script piped in with --script - is saved and run as
+	StdinScriptName = "command.sh"
+)
+
+// Script is a single local file run without syncing a repository
+type Script struct {
+	Name    string
+	Content []byte
+}
+
+var scriptInterpreters = map[string][]string{
+	".py": {"python"},
+	".sh": {"/bin/sh"},
+	".R":  {"Rscript"},
+	".jl": {"julia"},
+	".js": {"node"},
+	".ts": {"npx", "tsx"},
+}
+
+// ScriptCommand returns the interpreter command for a script file
+func ScriptCommand(name string) ([]string, error) {
+	interpreter, ok := scriptInterpreters[path.Ext(name)]
+	if !ok {
+		return nil, fmt.Errorf("don't know how to run %s; supported extensions are .py, .sh, .R, .jl, .js and .ts", name)
+	}
+	return append(append([]string{}, interpreter...), path.Join(ScriptMountPath, path.Base(name))), nil
+}
+
+func NewScript(name string, content []byte) (*Script, error) {
+	if len(content) > MaxScriptSize {
+		return nil, fmt.Errorf("%s is %d bytes; scripts are limited to %d bytes, commit larger code and use the repository instead", name, len(content), MaxScriptSize)
+	}
+	return &Script{Name: path.Base(name), Content: content}, nil
+}
+
+func (sc *Script) hash() string {
+	return fmt.Sprintf("%x", sha256.Sum256(sc.Content))
+}
+
+func makeScriptConfigMapName(runID string) string {
+	return strings.ToLower("qwex-script-" + runID)
+}
+
+// applyScript swaps the repository checkout for the script. The workspace and cache
+// PVCs are dropped too, since script runs must work without a dev workspace.
+func (s *Service) applyScript(podSpec *corev1.PodSpec, runID string) {
+	if s.Script == nil {
+		return
+	}
+
+	var initContainers []corev1.Container
+	for _, c := range podSpec.InitContainers {
+		if c.Name != InitContainerName {
+			initContainers = append(initContainers, c)
+		}
+	}
+	podSpec.InitContainers = initContainers
+
+	for i, v := range podSpec.Volumes {
+		switch v.Name {
+		case pods.WorkspaceVolumeName:
+			podSpec.Volumes[i].VolumeSource = corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: makeScriptConfigMapName(runID)},
+				},
+			}
+			podSpec.Volumes[i].Name = ScriptVolumeName
+		case pods.CacheVolumeName:
+			podSpec.Volumes[i].VolumeSource = corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}
+		}
+	}
+
+	for i := range podSpec.Containers {
+		c := &podSpec.Containers[i]
+		for j, m := range c.VolumeMounts {
+			if m.Name == pods.WorkspaceVolumeName {
+				c.VolumeMounts[j] = corev1.VolumeMount{Name: ScriptVolumeName, MountPath: ScriptMountPath, ReadOnly: true}
+			}
+		}
+	}
+}
+
+func (s *Service) createScriptConfigMap(ctx context.Context, runID string) (*corev1.ConfigMap, error) {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      makeScriptConfigMapName(runID),
+			Namespace: s.connector.Namespace,
+			Labels:    map[string]string{RunIDLabel: runID},
+		},
+		BinaryData: map[string][]byte{s.Script.Name: s.Script.Content},
+	}
+	created, err := s.connector.Client.CoreV1().ConfigMaps(s.connector.Namespace).Create(ctx, cm, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload script: %w", err)
+	}
+	return created, nil
+}
+
+// adoptScriptConfigMap makes the job own its script so both are garbage collected together
+func (s *Service) adoptScriptConfigMap(ctx context.Context, cm *corev1.ConfigMap, job *v1.Job) error {
+	cm.OwnerReferences = []metav1.OwnerReference{*metav1.NewControllerRef(job, v1.SchemeGroupVersion.WithKind("Job"))}
+	_, err := s.connector.Client.CoreV1().ConfigMaps(s.connector.Namespace).Update(ctx, cm, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to attach script to job %s: %w", job.Name, err)
+	}
+	return nil
+}
+
+// GetRunScript returns the script a run executed, or nil if it ran the repository
+func (s *Service) GetRunScript(ctx context.Context, runID string) (*Script, error) {
+	cm, err := s.connector.Client.CoreV1().ConfigMaps(s.connector.Namespace).Get(ctx, makeScriptConfigMapName(runID), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get script of run %s: %w", runID, err)
+	}
+	for name, content := range cm.BinaryData {
+		return &Script{Name: name, Content: content}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,79 @@
+package batch
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/Quatton/qwex/apps/qwexctl/internal/connect"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestScriptCommand(t *testing.T) {
+	command, err := ScriptCommand("analysis/plot.py")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(command, []string{"python", ScriptMountPath + "/plot.py"}) {
+		t.Errorf("unexpected command %v", command)
+	}
+
+	if _, err := ScriptCommand("notes.txt"); err == nil {
+		t.Error("expected an unknown extension to be rejected")
+	}
+	if _, err := NewScript("big.py", make([]byte, MaxScriptSize+1)); err == nil {
+		t.Error("expected an oversized script to be rejected")
+	}
+}
+
+func TestBuildScriptJobSpec(t *testing.T) {
+	script, err := NewScript("plot.py", []byte("print('hi')\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	command, _ := ScriptCommand(script.Name)
+
+	s := NewService(&connect.Service{Namespace: "ns"}, "", DemoImage, command, nil, BatchWorkDir, "plot")
+	s.Script = script
+
+	job, err := s.buildBatchJobSpec("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	podSpec := job.Spec.Template.Spec
+
+	if len(podSpec.InitContainers) != 0 {
+		t.Errorf("expected no repository checkout, got %d init containers", len(podSpec.InitContainers))
+	}
+	for _, v := range podSpec.Volumes {
+		if v.PersistentVolumeClaim != nil {
+			t.Errorf("expected no PVCs in a script job, got %s", v.Name)
+		}
+		if v.Name == ScriptVolumeName && v.ConfigMap.Name != makeScriptConfigMapName(job.Labels[RunIDLabel]) {
+			t.Errorf("unexpected script config map %s", v.ConfigMap.Name)
+		}
+	}
+}
+
+func TestGetRunScript(t *testing.T) {
+	client := fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: makeScriptConfigMapName("abc"), Namespace: "ns"},
+		BinaryData: map[string][]byte{StdinScriptName: []byte("echo hi\n")},
+	})
+	s := NewService(&connect.Service{Client: client, Namespace: "ns"}, "", "", nil, nil, "", "")
+
+	script, err := s.GetRunScript(context.Background(), "abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if script == nil || script.Name != StdinScriptName || string(script.Content) != "echo hi\n" {
+		t.Errorf("unexpected script %+v", script)
+	}
+
+	script, err = s.GetRunScript(context.Background(), "repo-run")
+	if err != nil || script != nil {
+		t.Errorf("expected no script for a repository run, got %+v, %v", script, err)
+	}
+}
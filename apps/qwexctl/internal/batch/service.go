@@ -3,8 +3,11 @@ package batch
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"regexp"
+	"slices"
 	"time"
 
 	"github.com/Quatton/qwex/apps/qwexctl/internal/connect"
@@ -23,6 +26,7 @@ const BatchWorkDir = "/batch"
 const BatchContainerName = "batchcontainer"
 const InitContainerName = pods.InitContainerName
 const BatchVolumeName = "batch"
+const RunIDLabel = "qwex.dev/run-id"
 
 type Service struct {
 	connector *connect.Service
@@ -31,6 +35,48 @@ type Service struct {
 	Args      []string
 	WorkDir   string
 	Name      string
+	SyncImage string
+	Workspace string
+
+	ConcurrencyGroup  string
+	ConcurrencyPolicy ConcurrencyPolicy
+
+	// DedupWindow reuses an identical succeeded run finished within this window (0 disables)
+	DedupWindow time.Duration
+
+	Inputs []Input
+	Env    []EnvVar
+
+	// Labels are extra user labels on the job and its pod, e.g. sweep=bert
+	Labels map[string]string
+
+	// Script runs a single uploaded file instead of the synced repository
+	Script *Script
+
+	// Resources override the default CPU and memory and request GPUs (nil keeps the defaults)
+	Resources *Resources
+
+	// Contract is checked after the command exits 0; a violation fails the run (nil disables)
+	Contract *Contract
+
+	// MaxDuration fails the run once it has been active this long (0 disables)
+	MaxDuration time.Duration
+
+	// TerminationGrace is how long a cancelled job has between SIGTERM and SIGKILL
+	// to clean up (0 keeps the Kubernetes default of 30s)
+	TerminationGrace time.Duration
+
+	// Scratch mounts a size-limited /scratch and caps the job's ephemeral storage (nil disables)
+	Scratch *resource.Quantity
+
+	// LogRange limits what log reads return; the zero value reads everything
+	LogRange LogRange
+
+	// OnWait is called with the run's phase while waiting for it to start
+	OnWait func(phase string)
+
+	// RedactPatterns are masked in streamed logs on top of DefaultRedactPatterns and injected secrets
+	RedactPatterns []*regexp.Regexp
 }
 
 func NewService(connector *connect.Service, sha, image string, command []string, args []string, workDir string, _name string) *Service {
@@ -45,6 +91,9 @@ func NewService(connector *connect.Service, sha, image string, command []string,
 		Args:      args,
 		WorkDir:   workDir,
 		Name:      name,
+		SyncImage: pods.SyncImage,
+
+		ConcurrencyPolicy: ConcurrencyQueue,
 	}
 }
 
@@ -61,18 +110,57 @@ func generateRunID(job string) string {
 	return fmt.Sprintf("%s-%s-%s", job, timestamp, uuidPart)
 }
 
+func (s *Service) jobEnv() []corev1.EnvVar {
+	return []corev1.EnvVar{
+		{
+			Name:  "XDG_CACHE_HOME",
+			Value: pods.CacheMountPath,
+		},
+		{
+			Name:  StatusFileEnv,
+			Value: StatusFilePath,
+		},
+		{
+			Name:  MetricsFileEnv,
+			Value: MetricsFilePath,
+		},
+	}
+}
+
+// EnvNames lists the variables set in the job environment
+func (s *Service) EnvNames() []string {
+	var names []string
+	for _, e := range s.jobEnv() {
+		names = append(names, e.Name)
+	}
+	for _, e := range s.Env {
+		names = append(names, e.Name)
+	}
+	return names
+}
+
 func (s *Service) buildBatchJobSpec(sha string) (*v1.Job, error) {
 	runID := generateRunID(s.Name)
-	ttl := int32(300)        // 5 minutes
+	ttl := int32(300) // 5 minutes
+	if s.DedupWindow > 5*time.Minute {
+		// Keep finished jobs around long enough to be deduplicated against
+		ttl = int32(s.DedupWindow.Seconds())
+	}
 	backoffLimit := int32(0) // Don't retry on failure
+
+	userEnv, err := s.renderEnv(runID, sha)
+	if err != nil {
+		return nil, err
+	}
+
 	job := &v1.Job{
 		ObjectMeta: metav1.ObjectMeta{
 			GenerateName: fmt.Sprintf("%s-", s.Name),
 			Namespace:    s.connector.Namespace,
 			Labels: map[string]string{
-				"qwex.dev/type":   "batch",
-				"qwex.dev/sha":    sha,
-				"qwex.dev/run-id": runID,
+				"qwex.dev/type": "batch",
+				"qwex.dev/sha":  sha,
+				RunIDLabel:      runID,
 			},
 		},
 		Spec: v1.JobSpec{
@@ -83,9 +171,9 @@ func (s *Service) buildBatchJobSpec(sha string) (*v1.Job, error) {
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
 					Labels: map[string]string{
-						"qwex.dev/type":   "batch",
-						"qwex.dev/sha":    sha,
-						"qwex.dev/run-id": runID,
+						"qwex.dev/type": "batch",
+						"qwex.dev/sha":  sha,
+						RunIDLabel:      runID,
 					},
 				},
 				Spec: corev1.PodSpec{
@@ -95,7 +183,7 @@ func (s *Service) buildBatchJobSpec(sha string) (*v1.Job, error) {
 							Name: pods.WorkspaceVolumeName,
 							VolumeSource: corev1.VolumeSource{
 								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
-									ClaimName: pods.MakePVCName(s.connector.Namespace),
+									ClaimName: pods.MakePVCName(pods.MakeWorkspacePrefix(s.connector.Namespace, s.Workspace)),
 								},
 							},
 						},
@@ -109,7 +197,7 @@ func (s *Service) buildBatchJobSpec(sha string) (*v1.Job, error) {
 							Name: pods.CacheVolumeName,
 							VolumeSource: corev1.VolumeSource{
 								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
-									ClaimName: pods.MakeCachePVCName(s.connector.Namespace),
+									ClaimName: pods.MakeCachePVCName(pods.MakeWorkspacePrefix(s.connector.Namespace, s.Workspace)),
 								},
 							},
 						},
@@ -117,7 +205,7 @@ func (s *Service) buildBatchJobSpec(sha string) (*v1.Job, error) {
 					InitContainers: []corev1.Container{
 						{
 							Name:    InitContainerName,
-							Image:   pods.SyncImage,
+							Image:   s.SyncImage,
 							Command: []string{"/bin/sh", "-c"},
 							Args: []string{
 								fmt.Sprintf(
@@ -171,22 +259,59 @@ func (s *Service) buildBatchJobSpec(sha string) (*v1.Job, error) {
 									corev1.ResourceMemory: resource.MustParse("8Gi"),
 								},
 							},
-							Env: []corev1.EnvVar{
-								{
-									Name:  "XDG_CACHE_HOME",
-									Value: pods.CacheMountPath,
-								},
-							},
+							Env: append(s.jobEnv(), userEnv...),
 						},
 					},
 				},
 			},
 		},
 	}
+
+	specHash, err := s.specHash(sha)
+	if err != nil {
+		return nil, err
+	}
+	job.Labels[SpecHashLabel] = specHash
+
+	if s.ConcurrencyGroup != "" {
+		job.Labels[ConcurrencyGroupLabel] = s.ConcurrencyGroup
+	}
+
+	for key, value := range s.Labels {
+		job.Labels[key] = value
+		job.Spec.Template.Labels[key] = value
+	}
+
+	podSpec := &job.Spec.Template.Spec
+	if len(s.Inputs) > 0 {
+		podSpec.InitContainers = append(podSpec.InitContainers, s.inputsInitContainer())
+	}
+	s.applyResources(podSpec)
+	s.applyScratch(podSpec)
+	s.applyScript(podSpec, runID)
+	s.applyContract(podSpec)
+
+	if s.MaxDuration > 0 {
+		deadline := int64(s.MaxDuration.Seconds())
+		job.Spec.ActiveDeadlineSeconds = &deadline
+	}
+	if s.TerminationGrace > 0 {
+		grace := int64(s.TerminationGrace.Seconds())
+		podSpec.TerminationGracePeriodSeconds = &grace
+	}
+
 	return job, nil
 
 }
 
+// Submit runs s.Script if set, otherwise syncs and runs the current commit
+func (s *Service) Submit(ctx context.Context) (*v1.Job, error) {
+	if s.Script != nil {
+		return s.submitJob(ctx, "")
+	}
+	return s.EnsureSyncAndSubmitJob(ctx)
+}
+
 func (s *Service) EnsureSyncAndSubmitJob(ctx context.Context) (*v1.Job, error) {
 	clean, err := s.connector.IsLocalStatusClean(ctx)
 	if err != nil {
@@ -208,35 +333,85 @@ func (s *Service) EnsureSyncAndSubmitJob(ctx context.Context) (*v1.Job, error) {
 		return nil, fmt.Errorf("failed to get remote head after sync: %w", err)
 	}
 
-	jobSpec, err := s.buildBatchJobSpec(remoteHead.CommitHash)
+	return s.submitJob(ctx, remoteHead.CommitHash)
+}
+
+func (s *Service) submitJob(ctx context.Context, sha string) (*v1.Job, error) {
+	jobSpec, err := s.buildBatchJobSpec(sha)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build batch job spec: %w", err)
 	}
 
+	pullSecrets, err := pods.NewService(s.connector.Client, s.connector.Namespace).ImagePullSecrets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	jobSpec.Spec.Template.Spec.ImagePullSecrets = pullSecrets
+
+	duplicate, err := s.findDuplicateRun(ctx, jobSpec.Labels[SpecHashLabel])
+	if err != nil {
+		return nil, err
+	}
+	if duplicate != nil {
+		return duplicate, nil
+	}
+
+	var scriptConfigMap *corev1.ConfigMap
+	if s.Script != nil {
+		scriptConfigMap, err = s.createScriptConfigMap(ctx, jobSpec.Labels[RunIDLabel])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if s.ConcurrencyGroup != "" {
+		// Held until acquireConcurrencyGroup decides it's this run's turn
+		suspend := true
+		jobSpec.Spec.Suspend = &suspend
+	}
+
 	jobsClient := s.connector.Client.BatchV1().Jobs(s.connector.Namespace)
 	job, err := jobsClient.Create(ctx, jobSpec, metav1.CreateOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create batch job: %w", err)
 	}
 
+	if scriptConfigMap != nil {
+		if err := s.adoptScriptConfigMap(ctx, scriptConfigMap, job); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.acquireConcurrencyGroup(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to acquire concurrency group %s: %w", s.ConcurrencyGroup, err)
+	}
+
 	return job, nil
 }
 
+func (s *Service) reportWait(pod *corev1.Pod) {
+	if s.OnWait != nil {
+		s.OnWait(RunPhase(pod))
+	}
+}
+
 func (s *Service) WaitForRunReady(ctx context.Context, runID string, timeout time.Duration) (string, error) {
 	interval := 2 * time.Second
 	var pod *corev1.Pod
 	err := wait.PollUntilContextTimeout(ctx, interval, timeout, true, func(ctx context.Context) (bool, error) {
 		podList, err := s.connector.Client.CoreV1().Pods(s.connector.Namespace).List(ctx, metav1.ListOptions{
-			LabelSelector: fmt.Sprintf("qwex.dev/run-id=%s", runID),
+			LabelSelector: fmt.Sprintf("%s=%s", RunIDLabel, runID),
 		})
 		if err != nil {
 			return false, err
 		}
 		if len(podList.Items) == 0 {
+			s.reportWait(nil)
 			return false, nil
 		}
 
 		pod = &podList.Items[0]
+		s.reportWait(pod)
 
 		if pod.Status.Phase == corev1.PodFailed {
 			return true, nil
@@ -279,21 +454,32 @@ func (s *Service) FollowRunLogs(ctx context.Context, runID string, writer io.Wri
 		return fmt.Errorf("error waiting for pod to be running: %w", err)
 	}
 
-	return s.streamLogsFromPod(ctx, podName, writer, true)
+	if err := s.streamLogsFromPod(ctx, podName, writer, true); err != nil {
+		return err
+	}
+	return s.checkRunFailure(ctx, podName)
 }
 
 func (s *Service) GetRunLogs(ctx context.Context, runID string) (string, error) {
+	var buf bytes.Buffer
+	var failed *RunFailedError
+	if err := s.WriteRunLogs(ctx, runID, &buf); err != nil && !errors.As(err, &failed) {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// WriteRunLogs streams the logs written so far without buffering them in memory
+func (s *Service) WriteRunLogs(ctx context.Context, runID string, writer io.Writer) error {
 	podName, err := s.WaitForRunReady(ctx, runID, 2*time.Minute)
 	if err != nil {
-		return "", fmt.Errorf("error waiting for pod to be running: %w", err)
+		return fmt.Errorf("error waiting for pod to be running: %w", err)
 	}
 
-	var buf bytes.Buffer
-	err = s.streamLogsFromPod(ctx, podName, &buf, false)
-	if err != nil {
-		return "", err
+	if err := s.streamLogsFromPod(ctx, podName, writer, false); err != nil {
+		return err
 	}
-	return buf.String(), nil
+	return s.checkRunFailure(ctx, podName)
 }
 
 type bytesWriter struct {
@@ -312,6 +498,14 @@ func (s *Service) streamLogsFromPod(ctx context.Context, podName string, writer
 		Container: BatchContainerName,
 		Follow:    follow,
 	}
+	s.LogRange.apply(logOptions)
+
+	pod, err := client.CoreV1().Pods(s.connector.Namespace).Get(ctx, podName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get pod %s: %w", podName, err)
+	}
+	patterns := slices.Concat(DefaultRedactPatterns, s.RedactPatterns, s.secretPatterns(ctx, pod))
+	redactor := NewRedactWriter(writer, patterns)
 
 	req := client.CoreV1().Pods(s.connector.Namespace).GetLogs(podName, logOptions)
 	stream, err := req.Stream(ctx)
@@ -320,10 +514,10 @@ func (s *Service) streamLogsFromPod(ctx context.Context, podName string, writer
 	}
 	defer stream.Close()
 
-	_, err = io.Copy(writer, stream)
+	_, err = io.Copy(redactor, stream)
 	if err != nil && err != io.EOF {
 		return fmt.Errorf("error reading logs: %w", err)
 	}
 
-	return nil
+	return redactor.Flush()
 }
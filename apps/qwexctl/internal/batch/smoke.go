@@ -0,0 +1,41 @@
+package batch
+
+import (
+	"maps"
+	"time"
+)
+
+const (
+	SmokeLabel = "qwex.dev/smoke"
+	SmokeEnv   = "QWEX_SMOKE"
+
+	// SmokeMaxDuration caps smoke runs; image pulls count towards it
+	SmokeMaxDuration = 5 * time.Minute
+)
+
+// SmokeResources are enough to import a framework and load a batch, not to train
+var SmokeResources = Resources{CPU: "500m", Memory: "1Gi"}
+
+// Smoke turns the run into a quick check of the command, image and env wiring:
+// minimal resources and no GPUs, QWEX_SMOKE=1 so the job can shrink its work,
+// a short deadline, and a label that keeps it apart from real runs
+func (s *Service) Smoke() {
+	resources := SmokeResources
+	s.Resources = &resources
+
+	s.Env = append(append([]EnvVar{}, s.Env...), EnvVar{Name: SmokeEnv, Value: "1"})
+
+	if s.MaxDuration <= 0 || s.MaxDuration > SmokeMaxDuration {
+		s.MaxDuration = SmokeMaxDuration
+	}
+
+	s.Labels = maps.Clone(s.Labels)
+	if s.Labels == nil {
+		s.Labels = map[string]string{}
+	}
+	s.Labels[SmokeLabel] = "true"
+}
+
+func IsSmokeRun(labels map[string]string) bool {
+	return labels[SmokeLabel] == "true"
+}
@@ -0,0 +1,38 @@
+package batch
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSmoke(t *testing.T) {
+	labels := map[string]string{"sweep": "bert"}
+	s := &Service{
+		Resources:   &Resources{CPU: "8", GPU: 2},
+		MaxDuration: 6 * time.Hour,
+		Labels:      labels,
+	}
+	s.Smoke()
+
+	if *s.Resources != SmokeResources {
+		t.Errorf("expected smoke resources, got %+v", s.Resources)
+	}
+	if s.MaxDuration != SmokeMaxDuration {
+		t.Errorf("expected the deadline capped to %s, got %s", SmokeMaxDuration, s.MaxDuration)
+	}
+	if len(s.Env) != 1 || s.Env[0].Name != SmokeEnv {
+		t.Errorf("expected %s in the env, got %+v", SmokeEnv, s.Env)
+	}
+	if !IsSmokeRun(s.Labels) || s.Labels["sweep"] != "bert" {
+		t.Errorf("expected the smoke label alongside user labels, got %v", s.Labels)
+	}
+	if IsSmokeRun(labels) {
+		t.Error("expected the caller's labels to be left alone")
+	}
+
+	short := &Service{MaxDuration: time.Minute}
+	short.Smoke()
+	if short.MaxDuration != time.Minute {
+		t.Errorf("expected a shorter deadline to be kept, got %s", short.MaxDuration)
+	}
+}
@@ -0,0 +1,109 @@
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/Quatton/qwex/apps/qwexctl/internal/pods"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Usage is one sample of the batch container's resource usage
+type Usage struct {
+	Time        time.Time
+	CPUMilli    int64
+	MemoryBytes int64
+}
+
+// podMetrics is the subset of metrics.k8s.io/v1beta1 PodMetrics we read
+type podMetrics struct {
+	Timestamp  metav1.Time `json:"timestamp"`
+	Containers []struct {
+		Name  string              `json:"name"`
+		Usage corev1.ResourceList `json:"usage"`
+	} `json:"containers"`
+}
+
+// SampleUsage reads the latest usage from metrics-server; samples refresh every ~15s on its side
+func (s *Service) SampleUsage(ctx context.Context, podName string) (Usage, error) {
+	raw, err := s.connector.Client.CoreV1().RESTClient().Get().
+		AbsPath("/apis/metrics.k8s.io/v1beta1/namespaces", s.connector.Namespace, "pods", podName).
+		DoRaw(ctx)
+	if err != nil {
+		return Usage{}, fmt.Errorf("failed to read metrics for pod %s (is metrics-server installed?): %w", podName, err)
+	}
+
+	var metrics podMetrics
+	if err := json.Unmarshal(raw, &metrics); err != nil {
+		return Usage{}, fmt.Errorf("failed to decode metrics for pod %s: %w", podName, err)
+	}
+
+	for _, c := range metrics.Containers {
+		if c.Name != BatchContainerName {
+			continue
+		}
+		return Usage{
+			Time:        metrics.Timestamp.Time,
+			CPUMilli:    c.Usage.Cpu().MilliValue(),
+			MemoryBytes: c.Usage.Memory().Value(),
+		}, nil
+	}
+	return Usage{}, fmt.Errorf("no metrics for container %s in pod %s yet", BatchContainerName, podName)
+}
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline scales values against ceiling (or the largest value when ceiling is 0)
+func Sparkline(values []float64, ceiling float64) string {
+	if ceiling <= 0 {
+		for _, v := range values {
+			ceiling = math.Max(ceiling, v)
+		}
+	}
+
+	var b strings.Builder
+	for _, v := range values {
+		i := 0
+		if ceiling > 0 {
+			i = int(math.Round(v / ceiling * float64(len(sparkBlocks)-1)))
+		}
+		b.WriteRune(sparkBlocks[min(max(i, 0), len(sparkBlocks)-1)])
+	}
+	return b.String()
+}
+
+// ContainerLimits returns the batch container's CPU (millicores) and memory limits, 0 if unset
+func ContainerLimits(pod *corev1.Pod) (cpuMilli int64, memoryBytes int64, gpus int64) {
+	for _, c := range pod.Spec.Containers {
+		if c.Name != BatchContainerName {
+			continue
+		}
+		limits := c.Resources.Limits
+		if q, ok := limits[corev1.ResourceCPU]; ok {
+			cpuMilli = q.MilliValue()
+		}
+		if q, ok := limits[corev1.ResourceMemory]; ok {
+			memoryBytes = q.Value()
+		}
+		if q, ok := limits[pods.GPUResourceName]; ok {
+			gpus = q.Value()
+		}
+	}
+	return cpuMilli, memoryBytes, gpus
+}
+
+func FormatBytes(n int64) string {
+	units := []string{"B", "Ki", "Mi", "Gi", "Ti"}
+	v := float64(n)
+	i := 0
+	for v >= 1024 && i < len(units)-1 {
+		v /= 1024
+		i++
+	}
+	return fmt.Sprintf("%.1f%s", v, units[i])
+}
@@ -0,0 +1,21 @@
+package batch
+
+import "testing"
+
+func TestSparkline(t *testing.T) {
+	if actual := Sparkline([]float64{0, 500, 1000, 2000}, 2000); actual != "▁▃▅█" {
+		t.Errorf("unexpected sparkline %q", actual)
+	}
+	if actual := Sparkline([]float64{1, 2}, 0); actual != "▅█" {
+		t.Errorf("unexpected auto-scaled sparkline %q", actual)
+	}
+	if actual := Sparkline([]float64{3000}, 2000); actual != "█" {
+		t.Errorf("expected values over the ceiling to clamp, got %q", actual)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	if actual := FormatBytes(8 << 30); actual != "8.0Gi" {
+		t.Errorf("unexpected %s", actual)
+	}
+}
@@ -0,0 +1,97 @@
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Quatton/qwex/apps/qwexctl/internal/connect"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+const (
+	// StatusFileEnv points jobs at a file they can write progress to, e.g.
+	// echo "epoch 37/100" > "$QWEX_STATUS_FILE", or JSON with phase, percent and message
+	StatusFileEnv  = "QWEX_STATUS_FILE"
+	StatusFilePath = "/tmp/qwex-status"
+
+	maxProgressLength = 60
+)
+
+type progressReport struct {
+	Phase   string   `json:"phase"`
+	Percent *float64 `json:"percent"`
+	Message string   `json:"message"`
+}
+
+// FormatProgress turns status file contents into a one-line summary
+func FormatProgress(content string) string {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return ""
+	}
+
+	var report progressReport
+	if strings.HasPrefix(content, "{") && json.Unmarshal([]byte(content), &report) == nil {
+		var parts []string
+		if report.Phase != "" {
+			parts = append(parts, report.Phase)
+		}
+		if report.Percent != nil {
+			parts = append(parts, fmt.Sprintf("%.0f%%", *report.Percent))
+		}
+		if report.Message != "" {
+			parts = append(parts, report.Message)
+		}
+		content = strings.Join(parts, ", ")
+	} else {
+		// Jobs that append rather than overwrite still show their latest line
+		lines := strings.Split(content, "\n")
+		content = strings.TrimSpace(lines[len(lines)-1])
+	}
+
+	if r := []rune(content); len(r) > maxProgressLength {
+		content = string(r[:maxProgressLength-1]) + "…"
+	}
+	return content
+}
+
+// ReadRunProgress reads what a running job last wrote to its status file, or "" if nothing
+func (s *Service) ReadRunProgress(ctx context.Context, pod *corev1.Pod) (string, error) {
+	if pod.Status.Phase != corev1.PodRunning {
+		return "", nil
+	}
+
+	exec := connect.NewService(s.connector.Client, s.connector.Config, s.connector.Namespace, pod.Name, BatchContainerName, "")
+	out, err := exec.RemoteExec(ctx, []string{"cat", StatusFilePath}, nil)
+
+	var exitErr utilexec.ExitError
+	if errors.As(err, &exitErr) {
+		// The job hasn't written a status yet
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read status of pod %s: %w", pod.Name, err)
+	}
+	return FormatProgress(out.Stdout), nil
+}
+
+// RunProgress reads the status file of a run's running pod, if it has one
+func (s *Service) RunProgress(ctx context.Context, runID string) (string, error) {
+	podList, err := s.connector.Client.CoreV1().Pods(s.connector.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", RunIDLabel, runID),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list pods for run %s: %w", runID, err)
+	}
+	for i := range podList.Items {
+		if podList.Items[i].Status.Phase == corev1.PodRunning {
+			return s.ReadRunProgress(ctx, &podList.Items[i])
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,22 @@
+package batch
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatProgress(t *testing.T) {
+	cases := map[string]string{
+		"":                               "",
+		"epoch 37/100\n":                 "epoch 37/100",
+		"epoch 1/100\nepoch 2/100\n":     "epoch 2/100",
+		`{"phase":"train","percent":62}`: "train, 62%",
+		`{"message":"eval on val set"}`:  "eval on val set",
+		strings.Repeat("x", 100):         strings.Repeat("x", 59) + "…",
+	}
+	for content, expected := range cases {
+		if actual := FormatProgress(content); actual != expected {
+			t.Errorf("FormatProgress(%q) = %q, expected %q", content, actual, expected)
+		}
+	}
+}
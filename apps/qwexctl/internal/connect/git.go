@@ -140,6 +140,10 @@ func (s *Service) CreateGitBundle(remote *RemoteState) (string, string, error) {
 		return "", "", fmt.Errorf("git add -A failed: %s %v", out, err)
 	}
 
+	if err := s.removeIgnoredFromIndex(env); err != nil {
+		return "", "", err
+	}
+
 	cmd = exec.Command("git", "-C", s.LocalRepoPath, "write-tree")
 	cmd.Env = env
 	out, err := cmd.Output()
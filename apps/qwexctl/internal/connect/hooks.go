@@ -0,0 +1,101 @@
+package connect
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+	"time"
+)
+
+const hookTimeout = 10 * time.Minute
+
+// SyncHook runs Run in the directory of any changed file matching one of Files
+type SyncHook struct {
+	Files []string `mapstructure:"files"`
+	Run   string   `mapstructure:"run"`
+}
+
+func DefaultSyncHooks() []SyncHook {
+	return []SyncHook{
+		{Files: []string{"uv.lock"}, Run: "uv sync"},
+		{Files: []string{"requirements.txt"}, Run: "pip install -r requirements.txt"},
+		{Files: []string{"package-lock.json"}, Run: "npm ci"},
+		{Files: []string{"bun.lock"}, Run: "bun install"},
+	}
+}
+
+type hookRun struct {
+	Dir     string
+	Command string
+}
+
+// matchHooks returns one run per (hook, directory) pair touched by the changed files
+func matchHooks(hooks []SyncHook, changed []string) []hookRun {
+	var runs []hookRun
+	seen := map[hookRun]bool{}
+
+	for _, hook := range hooks {
+		for _, file := range changed {
+			for _, pattern := range hook.Files {
+				if ok, _ := path.Match(pattern, path.Base(file)); !ok {
+					continue
+				}
+				run := hookRun{Dir: path.Dir(file), Command: hook.Run}
+				if !seen[run] {
+					seen[run] = true
+					runs = append(runs, run)
+				}
+			}
+		}
+	}
+	return runs
+}
+
+func (s *Service) changedFiles(ctx context.Context, from *RemoteState, to string) ([]string, error) {
+	cmd := []string{"git", "-C", "/workspace", "ls-tree", "-r", "--name-only", to}
+	if from != nil {
+		cmd = []string{"git", "-C", "/workspace", "diff", "--name-only", from.CommitHash, to}
+	}
+
+	output, err := s.RemoteExec(ctx, cmd, nil)
+	if err != nil {
+		if output != nil {
+			return nil, fmt.Errorf("failed to list changed files: %s", output.Stderr)
+		}
+		return nil, err
+	}
+	return strings.Fields(output.Stdout), nil
+}
+
+func (s *Service) runSyncHooks(ctx context.Context, from *RemoteState, to string) {
+	if len(s.SyncHooks) == 0 || s.HookContainerName == "" {
+		return
+	}
+
+	// Installs outlive the short sync timeouts used by exec
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), hookTimeout)
+	defer cancel()
+
+	changed, err := s.changedFiles(ctx, from, to)
+	if err != nil {
+		log.Printf("Skipping sync hooks: %v", err)
+		return
+	}
+
+	for _, run := range matchHooks(s.SyncHooks, changed) {
+		dir := path.Join("/workspace", run.Dir)
+		log.Printf("Dependencies changed in %s, running: %s", dir, run.Command)
+
+		script := fmt.Sprintf("cd %q && %s", dir, run.Command)
+		output, err := s.RemoteExecContainer(ctx, []string{"/bin/sh", "-c", script}, nil, s.HookContainerName)
+		if err != nil {
+			if output != nil {
+				log.Printf("Sync hook %q failed: %v | Stdout: %s | Stderr: %s", run.Command, err, output.Stdout, output.Stderr)
+			} else {
+				log.Printf("Sync hook %q failed to start: %v", run.Command, err)
+			}
+		}
+	}
+}
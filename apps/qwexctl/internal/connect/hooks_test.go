@@ -0,0 +1,30 @@
+package connect
+
+import "testing"
+
+func TestMatchHooks(t *testing.T) {
+	changed := []string{"uv.lock", "services/api/uv.lock", "README.md", "web/package-lock.json"}
+
+	runs := matchHooks(DefaultSyncHooks(), changed)
+
+	expected := []hookRun{
+		{Dir: ".", Command: "uv sync"},
+		{Dir: "services/api", Command: "uv sync"},
+		{Dir: "web", Command: "npm ci"},
+	}
+
+	if len(runs) != len(expected) {
+		t.Fatalf("Expected %d hook runs, got %d: %+v", len(expected), len(runs), runs)
+	}
+	for i := range expected {
+		if runs[i] != expected[i] {
+			t.Errorf("Expected %+v, got %+v", expected[i], runs[i])
+		}
+	}
+}
+
+func TestMatchHooksNoChanges(t *testing.T) {
+	if runs := matchHooks(DefaultSyncHooks(), []string{"main.py"}); len(runs) != 0 {
+		t.Fatalf("Expected no hook runs, got %+v", runs)
+	}
+}
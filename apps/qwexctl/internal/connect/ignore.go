@@ -0,0 +1,60 @@
+package connect
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+// IgnoreFileName lists files (gitignore syntax, one per directory) that are never shipped to the
+// cluster. It applies on top of .gitignore and also drops files that are tracked by git.
+const IgnoreFileName = ".qwexignore"
+
+// ReadIgnoreFile returns a matcher for dir/name, or nil if there is none.
+// Patterns are rooted at dir, so match against absolute paths.
+func ReadIgnoreFile(dir, name string) gitignore.Matcher {
+	f, err := os.Open(filepath.Join(dir, name))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	domain := strings.Split(filepath.ToSlash(dir), "/")
+	var patterns []gitignore.Pattern
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, gitignore.ParsePattern(line, domain))
+	}
+	return gitignore.NewMatcher(patterns)
+}
+
+// removeIgnoredFromIndex drops .qwexignore'd paths from the index selected by env
+func (s *Service) removeIgnoredFromIndex(env []string) error {
+	cmd := exec.Command("git", "-C", s.LocalRepoPath, "ls-files", "-z", "--cached", "--ignored", "--exclude-per-directory="+IgnoreFileName)
+	cmd.Env = env
+	ignored, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("failed to list %s matches: %w", IgnoreFileName, err)
+	}
+	if len(bytes.TrimSpace(ignored)) == 0 {
+		return nil
+	}
+
+	cmd = exec.Command("git", "-C", s.LocalRepoPath, "update-index", "--force-remove", "-z", "--stdin")
+	cmd.Env = env
+	cmd.Stdin = bytes.NewReader(ignored)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to drop %s matches: %s %v", IgnoreFileName, out, err)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package connect
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReadIgnoreFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, IgnoreFileName), []byte("# data\ndata/\n*.ckpt\n!keep.ckpt\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := ReadIgnoreFile(dir, IgnoreFileName)
+	if m == nil {
+		t.Fatal("expected a matcher")
+	}
+
+	parts := func(rel string) []string {
+		return strings.Split(filepath.ToSlash(filepath.Join(dir, rel)), "/")
+	}
+	if !m.Match(parts("data"), true) || !m.Match(parts("model.ckpt"), false) {
+		t.Error("expected data/ and *.ckpt to be ignored")
+	}
+	if m.Match(parts("keep.ckpt"), false) || m.Match(parts("train.py"), false) {
+		t.Error("expected keep.ckpt and train.py to be kept")
+	}
+
+	if ReadIgnoreFile(t.TempDir(), IgnoreFileName) != nil {
+		t.Error("expected no matcher without an ignore file")
+	}
+}
+
+func TestRemoveIgnoredFromIndex(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	dir := t.TempDir()
+	files := map[string]string{
+		IgnoreFileName:          "*.ckpt\n",
+		"train.py":              "print('hi')\n",
+		"model.ckpt":            "weights",
+		"sub/" + IgnoreFileName: "local/\n",
+		"sub/local/big.bin":     "x",
+		"sub/keep.txt":          "y",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	env := append(os.Environ(), "GIT_INDEX_FILE="+filepath.Join(t.TempDir(), "index"))
+	for _, args := range [][]string{{"init", "-q"}, {"add", "-A"}} {
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		cmd.Env = env
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %s", args, out)
+		}
+	}
+
+	s := &Service{LocalRepoPath: dir}
+	if err := s.removeIgnoredFromIndex(env); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command("git", "-C", dir, "ls-files", "--cached")
+	cmd.Env = env
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	indexed := strings.Fields(string(out))
+	slices.Sort(indexed)
+
+	expected := []string{IgnoreFileName, "sub/" + IgnoreFileName, "sub/keep.txt", "train.py"}
+	if !slices.Equal(indexed, expected) {
+		t.Errorf("expected %v, got %v", expected, indexed)
+	}
+}
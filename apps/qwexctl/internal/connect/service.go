@@ -17,6 +17,10 @@ type Service struct {
 	PodName       string
 	ContainerName string
 	LocalRepoPath string
+
+	// SyncHooks run in HookContainerName after a sync changes matching files
+	SyncHooks         []SyncHook
+	HookContainerName string
 }
 
 func GetLocalRepoPath(cfgFile string) string {
@@ -20,5 +20,7 @@ func (s *Service) SyncOnce(ctx context.Context) error {
 		return err
 	}
 
+	s.runSyncHooks(ctx, remoteHash, targetHash)
+
 	return nil
 }
@@ -18,21 +18,24 @@ const (
 	DevelopmentDeploymentSuffix = "dev"
 	DevContainerName            = "devcontainer"
 
-	// TODO: Make this configurable
+	// Defaults, overridable through Service.Images
 	DevelopmentDemoImage = "ghcr.io/astral-sh/uv:0.9.13-python3.12-bookworm"
 
 	SyncContainerName = "synccontainer"
 	SyncImage         = "alpine/git:latest"
 )
 
-func makeDevelopmentName(namespace string) string {
-	return fmt.Sprintf("%s-%s", namespace, DevelopmentDeploymentSuffix)
+// The helpers below take a workspace prefix, see MakeWorkspacePrefix.
+// For the default workspace the prefix is the namespace itself.
+
+func makeDevelopmentName(prefix string) string {
+	return fmt.Sprintf("%s-%s", prefix, DevelopmentDeploymentSuffix)
 }
 
-func MakePVCName(namespace string) string {
-	return fmt.Sprintf("%s-%s", namespace, WorkspacePVCNameSuffix)
+func MakePVCName(prefix string) string {
+	return fmt.Sprintf("%s-%s", prefix, WorkspacePVCNameSuffix)
 }
 
-func MakeCachePVCName(namespace string) string {
-	return fmt.Sprintf("%s-%s", namespace, CachePVCNameSuffix)
+func MakeCachePVCName(prefix string) string {
+	return fmt.Sprintf("%s-%s", prefix, CachePVCNameSuffix)
 }
@@ -24,11 +24,11 @@ const (
 	Hibernate DevelopmentMode = "hibernate"
 )
 
-func makeContainers(mode DevelopmentMode) []corev1.Container {
+func makeContainers(mode DevelopmentMode, gpu GPUOptions, images Images) []corev1.Container {
 	containers := []corev1.Container{
 		{
 			Name:            SyncContainerName,
-			Image:           SyncImage,
+			Image:           images.Sync,
 			ImagePullPolicy: corev1.PullIfNotPresent,
 			Command: []string{
 				"/bin/sh",
@@ -64,7 +64,7 @@ func makeContainers(mode DevelopmentMode) []corev1.Container {
 	if mode == Active {
 		devContainer := corev1.Container{
 			Name:            DevContainerName,
-			Image:           DevelopmentDemoImage,
+			Image:           images.Dev,
 			ImagePullPolicy: corev1.PullIfNotPresent,
 			Command:         []string{"/bin/sh", "-c", "tail -f /dev/null"},
 			VolumeMounts: []corev1.VolumeMount{
@@ -95,6 +95,7 @@ func makeContainers(mode DevelopmentMode) []corev1.Container {
 				},
 			},
 		}
+		gpu.applyToContainer(&devContainer, images.GPU)
 
 		containers = append(containers, devContainer)
 	}
@@ -102,20 +103,24 @@ func makeContainers(mode DevelopmentMode) []corev1.Container {
 	return containers
 }
 
-func (s *Service) buildDesiredDeployment(mode DevelopmentMode) *appsv1.Deployment {
-	name := makeDevelopmentName(s.Namespace)
+func (s *Service) buildDesiredDeployment(mode DevelopmentMode, gpu GPUOptions, pullSecrets []corev1.LocalObjectReference) *appsv1.Deployment {
+	name := makeDevelopmentName(s.workspacePrefix())
 	replica := int32(1)
+	images := s.Images.withDefaults()
 
 	hashSource := struct {
 		C  []corev1.Container
 		IC []corev1.Container
 		V  []corev1.Volume
+		NS map[string]string             `json:",omitempty"`
+		T  []corev1.Toleration           `json:",omitempty"`
+		PS []corev1.LocalObjectReference `json:",omitempty"`
 	}{
-		C: makeContainers(mode),
+		C: makeContainers(mode, gpu, images),
 		IC: []corev1.Container{
 			{
 				Name:            InitContainerName,
-				Image:           SyncImage,
+				Image:           images.Sync,
 				ImagePullPolicy: corev1.PullIfNotPresent,
 				WorkingDir:      WorkspaceMountPath,
 				Command: []string{
@@ -146,7 +151,7 @@ func (s *Service) buildDesiredDeployment(mode DevelopmentMode) *appsv1.Deploymen
 				Name: WorkspaceVolumeName,
 				VolumeSource: corev1.VolumeSource{
 					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
-						ClaimName: MakePVCName(s.Namespace),
+						ClaimName: MakePVCName(s.workspacePrefix()),
 					},
 				},
 			},
@@ -154,11 +159,14 @@ func (s *Service) buildDesiredDeployment(mode DevelopmentMode) *appsv1.Deploymen
 				Name: CacheVolumeName,
 				VolumeSource: corev1.VolumeSource{
 					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
-						ClaimName: MakeCachePVCName(s.Namespace),
+						ClaimName: MakeCachePVCName(s.workspacePrefix()),
 					},
 				},
 			},
 		},
+		NS: gpu.nodeSelector(),
+		T:  gpu.tolerations(),
+		PS: pullSecrets,
 	}
 
 	specHash, _ := calculateHash(hashSource)
@@ -168,8 +176,10 @@ func (s *Service) buildDesiredDeployment(mode DevelopmentMode) *appsv1.Deploymen
 			Name: name,
 			Labels: map[string]string{
 				DeploymentLabel:      name,
+				WorkspaceLabel:       s.workspaceName(),
 				"qwex.dev/spec-hash": fmt.Sprintf("%d", specHash),
 			},
+			Annotations: gpu.annotations(),
 		},
 		Spec: appsv1.DeploymentSpec{
 			Replicas: &replica,
@@ -185,9 +195,12 @@ func (s *Service) buildDesiredDeployment(mode DevelopmentMode) *appsv1.Deploymen
 					},
 				},
 				Spec: corev1.PodSpec{
-					Containers:     hashSource.C,
-					InitContainers: hashSource.IC,
-					Volumes:        hashSource.V,
+					Containers:       hashSource.C,
+					InitContainers:   hashSource.IC,
+					Volumes:          hashSource.V,
+					NodeSelector:     hashSource.NS,
+					Tolerations:      hashSource.T,
+					ImagePullSecrets: hashSource.PS,
 				},
 			},
 		},
@@ -218,8 +231,8 @@ func (s *Service) GetPodFromDeployment(ctx context.Context, deployment *appsv1.D
 func (s *Service) GetOrCreateDevelopmentDeployment(ctx context.Context, mode DevelopmentMode) (*appsv1.Deployment, error) {
 
 	// Ensure PVC exists
-	workspacePVC := createPVCSpec(s.Namespace, WorkspacePVCNameSuffix, "2Gi")
-	cachePVC := createPVCSpec(s.Namespace, CachePVCNameSuffix, "20Gi")
+	workspacePVC := createPVCSpec(s.Namespace, MakePVCName(s.workspacePrefix()), "2Gi")
+	cachePVC := createPVCSpec(s.Namespace, MakeCachePVCName(s.workspacePrefix()), "20Gi")
 
 	_, err := s.GetOrCreatePVC(ctx, workspacePVC)
 
@@ -232,12 +245,14 @@ func (s *Service) GetOrCreateDevelopmentDeployment(ctx context.Context, mode Dev
 		return nil, fmt.Errorf("failed to ensure cache PVC exists in namespace %s: %w", s.Namespace, err)
 	}
 
-	// TODO: Hibernate mode support
-	desired := s.buildDesiredDeployment(Active)
+	pullSecrets, err := s.ImagePullSecrets(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	var current *appsv1.Deployment
 
-	name := makeDevelopmentName(s.Namespace)
+	name := makeDevelopmentName(s.workspacePrefix())
 
 	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		var getErr error
@@ -245,6 +260,8 @@ func (s *Service) GetOrCreateDevelopmentDeployment(ctx context.Context, mode Dev
 
 		if getErr != nil {
 			if k8serrors.IsNotFound(getErr) {
+				// TODO: Hibernate mode support
+				desired := s.buildDesiredDeployment(Active, s.gpuOptions(nil), pullSecrets)
 				log.Printf("Development deployment %s not found, creating...", name)
 				created, createErr := s.K8s.AppsV1().Deployments(s.Namespace).Create(ctx, desired, metav1.CreateOptions{})
 				if createErr != nil {
@@ -256,6 +273,7 @@ func (s *Service) GetOrCreateDevelopmentDeployment(ctx context.Context, mode Dev
 			return getErr
 		}
 
+		desired := s.buildDesiredDeployment(Active, s.gpuOptions(current), pullSecrets)
 		if isDeploymentEqual(current, desired) {
 			return nil
 		}
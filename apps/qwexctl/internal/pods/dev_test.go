@@ -69,3 +69,55 @@ func TestDestroyDevPod(t *testing.T) {
 
 	t.Logf("Destroyed dev pod in namespace: %s", testNamespace)
 }
+
+func TestBuildDesiredDeploymentGPU(t *testing.T) {
+	service := NewService(nil, testNamespace)
+
+	cpu := service.buildDesiredDeployment(Active, GPUOptions{}, nil)
+	gpu := service.buildDesiredDeployment(Active, GPUOptions{Count: 2, Type: "NVIDIA-A100-SXM4-80GB"}, nil)
+
+	if isDeploymentEqual(cpu, gpu) {
+		t.Fatalf("Expected GPU deployment to have a different spec hash")
+	}
+
+	if cpu.Spec.Template.Spec.NodeSelector != nil || cpu.Spec.Template.Spec.Tolerations != nil {
+		t.Fatalf("Expected no GPU scheduling constraints without GPUs")
+	}
+
+	if gpu.Spec.Template.Spec.NodeSelector[GPUProductLabel] != "NVIDIA-A100-SXM4-80GB" {
+		t.Fatalf("Expected GPU product node selector, got %v", gpu.Spec.Template.Spec.NodeSelector)
+	}
+
+	for _, c := range gpu.Spec.Template.Spec.Containers {
+		if c.Name != DevContainerName {
+			continue
+		}
+		limit := c.Resources.Limits[GPUResourceName]
+		if limit.Value() != 2 {
+			t.Fatalf("Expected 2 GPUs on the dev container, got %s", limit.String())
+		}
+		if c.Image != DevelopmentGPUImage {
+			t.Fatalf("Expected GPU image, got %s", c.Image)
+		}
+	}
+
+	service.GPU = nil
+	if resolved := service.gpuOptions(gpu); resolved.Count != 2 {
+		t.Fatalf("Expected GPU options to be kept from the current deployment, got %+v", resolved)
+	}
+}
+
+func TestMakeWorkspacePrefix(t *testing.T) {
+	if prefix := MakeWorkspacePrefix(testNamespace, ""); makeDevelopmentName(prefix) != "qwex-demo-dev" {
+		t.Fatalf("Expected default workspace to keep existing names, got %s", prefix)
+	}
+
+	prefix := MakeWorkspacePrefix(testNamespace, "feature-x")
+	if MakePVCName(prefix) != "qwex-demo-feature-x-workspace-pvc" {
+		t.Fatalf("Unexpected PVC name for named workspace: %s", MakePVCName(prefix))
+	}
+
+	if err := ValidateWorkspaceName("Feature_X"); err == nil {
+		t.Fatalf("Expected invalid workspace name to be rejected")
+	}
+}
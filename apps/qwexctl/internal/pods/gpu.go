@@ -0,0 +1,83 @@
+package pods
+
+import (
+	"strconv"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	GPUResourceName = corev1.ResourceName("nvidia.com/gpu")
+	// Set by NVIDIA GPU feature discovery, e.g. NVIDIA-A100-SXM4-80GB
+	GPUProductLabel = "nvidia.com/gpu.product"
+
+	GPUCountAnnotation = "qwex.dev/gpu-count"
+	GPUTypeAnnotation  = "qwex.dev/gpu-type"
+
+	DevelopmentGPUImage = "pytorch/pytorch:2.5.1-cuda12.4-cudnn9-runtime"
+)
+
+type GPUOptions struct {
+	Count int
+	Type  string
+}
+
+// gpuOptions resolves the GPU setup: explicit options win, otherwise keep what is deployed
+func (s *Service) gpuOptions(current *appsv1.Deployment) GPUOptions {
+	if s.GPU != nil {
+		return *s.GPU
+	}
+	if current == nil {
+		return GPUOptions{}
+	}
+
+	count, _ := strconv.Atoi(current.Annotations[GPUCountAnnotation])
+	return GPUOptions{
+		Count: count,
+		Type:  current.Annotations[GPUTypeAnnotation],
+	}
+}
+
+func (g GPUOptions) applyToContainer(c *corev1.Container, image string) {
+	if g.Count <= 0 {
+		return
+	}
+
+	c.Image = image
+	if c.Resources.Limits == nil {
+		c.Resources.Limits = corev1.ResourceList{}
+	}
+	c.Resources.Limits[GPUResourceName] = resource.MustParse(strconv.Itoa(g.Count))
+}
+
+func (g GPUOptions) nodeSelector() map[string]string {
+	if g.Count <= 0 || g.Type == "" {
+		return nil
+	}
+	return map[string]string{GPUProductLabel: g.Type}
+}
+
+func (g GPUOptions) tolerations() []corev1.Toleration {
+	if g.Count <= 0 {
+		return nil
+	}
+	return []corev1.Toleration{
+		{
+			Key:      string(GPUResourceName),
+			Operator: corev1.TolerationOpExists,
+			Effect:   corev1.TaintEffectNoSchedule,
+		},
+	}
+}
+
+func (g GPUOptions) annotations() map[string]string {
+	if g.Count <= 0 {
+		return nil
+	}
+	return map[string]string{
+		GPUCountAnnotation: strconv.Itoa(g.Count),
+		GPUTypeAnnotation:  g.Type,
+	}
+}
@@ -0,0 +1,49 @@
+package pods
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Images struct {
+	Dev  string
+	GPU  string
+	Sync string
+}
+
+func DefaultImages() Images {
+	return Images{
+		Dev:  DevelopmentDemoImage,
+		GPU:  DevelopmentGPUImage,
+		Sync: SyncImage,
+	}
+}
+
+// withDefaults fills unset images so zero-value Services keep working
+func (i Images) withDefaults() Images {
+	defaults := DefaultImages()
+	if i.Dev == "" {
+		i.Dev = defaults.Dev
+	}
+	if i.GPU == "" {
+		i.GPU = defaults.GPU
+	}
+	if i.Sync == "" {
+		i.Sync = defaults.Sync
+	}
+	return i
+}
+
+// ValidateImage checks an image against an allowlist of prefixes (e.g. "ghcr.io/my-org/").
+// An empty allowlist allows everything.
+func ValidateImage(image string, allowed []string) error {
+	if len(allowed) == 0 {
+		return nil
+	}
+	for _, prefix := range allowed {
+		if strings.HasPrefix(image, prefix) {
+			return nil
+		}
+	}
+	return fmt.Errorf("image %s is not allowed (allowed: %s)", image, strings.Join(allowed, ", "))
+}
@@ -18,11 +18,11 @@ func shouldPatchPVC(existing, desired *corev1.PersistentVolumeClaim) bool {
 }
 
 func createPVCSpec(
-	namespace, suffix string,
+	namespace, name string,
 	storageSize string) *corev1.PersistentVolumeClaim {
 	pvcSpec := &corev1.PersistentVolumeClaim{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      fmt.Sprintf("%s-%s", namespace, suffix),
+			Name:      name,
 			Namespace: namespace,
 		},
 		Spec: corev1.PersistentVolumeClaimSpec{
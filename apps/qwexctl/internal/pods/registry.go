@@ -0,0 +1,117 @@
+package pods
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	RegistrySecretLabel      = "qwex.dev/registry"
+	RegistryServerAnnotation = "qwex.dev/registry-server"
+
+	registrySecretPrefix = "qwex-registry-"
+)
+
+var nonDNSChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+func MakeRegistrySecretName(server string) string {
+	name := nonDNSChars.ReplaceAllString(strings.ToLower(server), "-")
+	name = registrySecretPrefix + strings.Trim(name, "-")
+	if len(name) > 63 {
+		name = strings.TrimRight(name[:63], "-")
+	}
+	return name
+}
+
+func makeDockerConfigJSON(server, username, password string) ([]byte, error) {
+	auth := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+	return json.Marshal(map[string]any{
+		"auths": map[string]any{
+			server: map[string]string{
+				"username": username,
+				"password": password,
+				"auth":     auth,
+			},
+		},
+	})
+}
+
+// UpsertRegistrySecret stores credentials for a registry; logging in again rotates them
+func (s *Service) UpsertRegistrySecret(ctx context.Context, server, username, password string) (*corev1.Secret, error) {
+	config, err := makeDockerConfigJSON(server, username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	desired := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        MakeRegistrySecretName(server),
+			Namespace:   s.Namespace,
+			Labels:      map[string]string{RegistrySecretLabel: "true"},
+			Annotations: map[string]string{RegistryServerAnnotation: server},
+		},
+		Type: corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{corev1.DockerConfigJsonKey: config},
+	}
+
+	secrets := s.K8s.CoreV1().Secrets(s.Namespace)
+	current, err := secrets.Get(ctx, desired.Name, metav1.GetOptions{})
+	if err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to get registry secret %s: %w", desired.Name, err)
+		}
+		created, err := secrets.Create(ctx, desired, metav1.CreateOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create registry secret %s: %w", desired.Name, err)
+		}
+		return created, nil
+	}
+
+	desired.ResourceVersion = current.ResourceVersion
+	updated, err := secrets.Update(ctx, desired, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update registry secret %s: %w", desired.Name, err)
+	}
+	return updated, nil
+}
+
+func (s *Service) DeleteRegistrySecret(ctx context.Context, server string) error {
+	name := MakeRegistrySecretName(server)
+	err := s.K8s.CoreV1().Secrets(s.Namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete registry secret %s: %w", name, err)
+	}
+	return nil
+}
+
+func (s *Service) ListRegistrySecrets(ctx context.Context) ([]corev1.Secret, error) {
+	secretList, err := s.K8s.CoreV1().Secrets(s.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=true", RegistrySecretLabel),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list registry secrets: %w", err)
+	}
+	return secretList.Items, nil
+}
+
+// ImagePullSecrets returns references to every registry secret in the namespace
+func (s *Service) ImagePullSecrets(ctx context.Context) ([]corev1.LocalObjectReference, error) {
+	secrets, err := s.ListRegistrySecrets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []corev1.LocalObjectReference
+	for _, secret := range secrets {
+		refs = append(refs, corev1.LocalObjectReference{Name: secret.Name})
+	}
+	return refs, nil
+}
@@ -7,11 +7,19 @@ import (
 type Service struct {
 	K8s       kubernetes.Interface
 	Namespace string
+	// Workspace selects a named dev environment; empty is the default one
+	Workspace string
+
+	// GPU overrides the dev container GPU setup; nil keeps the deployed one
+	GPU *GPUOptions
+
+	Images Images
 }
 
 func NewService(k8sClient kubernetes.Interface, namespace string) *Service {
 	return &Service{
 		K8s:       k8sClient,
 		Namespace: namespace,
+		Images:    DefaultImages(),
 	}
 }
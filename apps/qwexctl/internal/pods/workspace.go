@@ -0,0 +1,83 @@
+package pods
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	WorkspaceLabel   = "qwex.dev/workspace"
+	DefaultWorkspace = "default"
+)
+
+type WorkspaceInfo struct {
+	Name       string
+	Deployment string
+	Ready      bool
+	GPUs       string
+	CreatedAt  time.Time
+}
+
+// MakeWorkspacePrefix names resources of a workspace. The default workspace keeps
+// the bare namespace so existing deployments and PVCs are reused.
+func MakeWorkspacePrefix(namespace, workspace string) string {
+	if workspace == "" || workspace == DefaultWorkspace {
+		return namespace
+	}
+	return fmt.Sprintf("%s-%s", namespace, workspace)
+}
+
+func ValidateWorkspaceName(name string) error {
+	if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+		return fmt.Errorf("invalid workspace name %q: %s", name, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (s *Service) workspaceName() string {
+	if s.Workspace == "" {
+		return DefaultWorkspace
+	}
+	return s.Workspace
+}
+
+func (s *Service) workspacePrefix() string {
+	return MakeWorkspacePrefix(s.Namespace, s.Workspace)
+}
+
+func (s *Service) ListWorkspaces(ctx context.Context) ([]WorkspaceInfo, error) {
+	deps, err := s.K8s.AppsV1().Deployments(s.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: DeploymentLabel,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workspaces in namespace %s: %w", s.Namespace, err)
+	}
+
+	var workspaces []WorkspaceInfo
+	for _, dep := range deps.Items {
+		name := dep.Labels[WorkspaceLabel]
+		if name == "" {
+			// Deployments created before named workspaces existed
+			name = DefaultWorkspace
+		}
+
+		desired := int32(1)
+		if dep.Spec.Replicas != nil {
+			desired = *dep.Spec.Replicas
+		}
+
+		workspaces = append(workspaces, WorkspaceInfo{
+			Name:       name,
+			Deployment: dep.Name,
+			Ready:      dep.Status.ReadyReplicas >= desired,
+			GPUs:       dep.Annotations[GPUCountAnnotation],
+			CreatedAt:  dep.CreationTimestamp.Time,
+		})
+	}
+	return workspaces, nil
+}
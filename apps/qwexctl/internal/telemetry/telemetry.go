@@ -0,0 +1,107 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime"
+	"time"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	EnabledKey  = "telemetry.enabled"
+	EndpointKey = "telemetry.endpoint"
+	InstallKey  = "telemetry.install-id"
+
+	sendTimeout = 2 * time.Second
+)
+
+// Event is the only payload we ever send. It must never carry code, args, or env.
+type Event struct {
+	InstallID  string    `json:"install_id"`
+	Command    string    `json:"command"`
+	ErrorClass string    `json:"error_class,omitempty"`
+	Version    string    `json:"version"`
+	OS         string    `json:"os"`
+	Arch       string    `json:"arch"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+type Client struct {
+	Endpoint  string
+	InstallID string
+	Version   string
+	HTTP      *http.Client
+}
+
+func NewClient(endpoint, installID, version string) *Client {
+	return &Client{
+		Endpoint:  endpoint,
+		InstallID: installID,
+		Version:   version,
+		HTTP:      &http.Client{Timeout: sendTimeout},
+	}
+}
+
+// ErrorClass reduces an error to a coarse, non-identifying category
+func ErrorClass(err error) string {
+	if err == nil {
+		return ""
+	}
+	if reason := k8serrors.ReasonForError(err); reason != "" {
+		return string(reason)
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return "timeout"
+	}
+	return "error"
+}
+
+func (c *Client) NewEvent(command string, err error) Event {
+	return Event{
+		InstallID:  c.InstallID,
+		Command:    command,
+		ErrorClass: ErrorClass(err),
+		Version:    c.Version,
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		Timestamp:  time.Now().UTC(),
+	}
+}
+
+// Send is best-effort: telemetry must never fail or slow down a command
+func (c *Client) Send(ctx context.Context, event Event) error {
+	if c.Endpoint == "" {
+		return nil
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("telemetry endpoint returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestErrorClass(t *testing.T) {
+	notFound := k8serrors.NewNotFound(schema.GroupResource{Resource: "pods"}, "secret-pod-name")
+
+	cases := map[string]struct {
+		err      error
+		expected string
+	}{
+		"nil":       {nil, ""},
+		"k8s":       {notFound, "NotFound"},
+		"timeout":   {context.DeadlineExceeded, "timeout"},
+		"arbitrary": {errors.New("open /home/alice/secret.txt"), "error"},
+	}
+
+	for name, tc := range cases {
+		if actual := ErrorClass(tc.err); actual != tc.expected {
+			t.Errorf("%s: expected %q, got %q", name, tc.expected, actual)
+		}
+	}
+}
+
+func TestSend(t *testing.T) {
+	var received Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("Expected JSON body, got %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "install-1", "v0.0.1")
+	if err := client.Send(t.Context(), client.NewEvent("qwexctl batch", nil)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if received.Command != "qwexctl batch" || received.InstallID != "install-1" {
+		t.Fatalf("Unexpected event: %+v", received)
+	}
+}
+
+func TestSendWithoutEndpoint(t *testing.T) {
+	client := NewClient("", "install-1", "v0.0.1")
+	if err := client.Send(t.Context(), client.NewEvent("qwexctl batch", nil)); err != nil {
+		t.Fatalf("Expected no-op without endpoint, got %v", err)
+	}
+}